@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/owenrumney/branch/internal/branch"
 	"github.com/owenrumney/branch/internal/config"
@@ -10,47 +15,348 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func newBranchCmd(branchType, description string) *cobra.Command {
-	return &cobra.Command{
-		Use:   fmt.Sprintf("%s [description...]", branchType),
-		Short: description,
+func newBranchCmd(cfg *config.Config, repo git.Repository, command config.BranchCommand) *cobra.Command {
+	branchType := command.BranchType()
+	description := command.Description
+	if description == "" {
+		description = fmt.Sprintf("Create a %s branch", branchType)
+	}
+
+	var (
+		from     string
+		fetchRef bool
+		dryRun   bool
+		worktree string
+		stash    bool
+		carry    bool
+		clean    bool
+		move     bool
+		push     bool
+		policy   string
+		orSwitch bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     fmt.Sprintf("%s [description...]", command.Name),
+		Aliases: command.Aliases,
+		Short:   description,
 		Long: fmt.Sprintf(`%s
 
-If the first word matches a known ticket pattern (e.g., PIP-1234, #123), it will be included in the branch name.
+If a word matches a known ticket pattern (e.g., PIP-1234, #123), it will be included in the branch name as the ticket.
 
 Examples:
   branch %s PIP-1234 implement new feature  ->  %s/pip-1234-implement-new-feature
-  branch %s implement new feature           ->  %s/implement-new-feature`, description, branchType, branchType, branchType, branchType),
+  branch %s implement new feature           ->  %s/implement-new-feature
+
+The branch starts from --from, the command's base, the default_base config setting, or the current HEAD.
+With --worktree it is checked out in a new worktree instead, at the given path or the worktree_dir config setting.
+
+If the name is already taken locally or on the remote, --on-collision (or the on_collision config setting) decides whether to fail, switch to the existing branch, or add a numbered suffix.
+--or-switch makes rerunning the same command switch to the branch it created before.
+With --push the new branch is pushed to the remote and set as its upstream; an existing branch switched to is pushed too unless the remote already has it.
+
+Uncommitted changes are carried over to the new branch unless --stash, --move, --require-clean or the dirty_policy config setting says otherwise.`, description, command.Name, branchType, command.Name, branchType),
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg, err := config.Load()
+			result, err := generateName(cfg, repo, branchType, args)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
-				cfg = config.Default()
+				fmt.Fprintf(os.Stderr, "Error generating branch name: %v\n", err)
+				os.Exit(1)
 			}
+			branchName := result.Name
 
-			ticket, descParts := parseArgs(args, cfg)
-			branchName := branch.Generate(branchType, ticket, descParts)
+			if dryRun {
+				fmt.Fprintln(cmd.OutOrStdout(), branchName)
+				return
+			}
+
+			dirtyPolicy := cfg.DirtyPolicy
+			switch {
+			case stash:
+				dirtyPolicy = git.DirtyStash
+			case carry:
+				dirtyPolicy = git.DirtyCarry
+			case clean:
+				dirtyPolicy = git.DirtyRequireClean
+			case move:
+				dirtyPolicy = git.DirtyMove
+			}
 
-			if err := git.CreateBranch(branchName); err != nil {
+			if orSwitch {
+				policy = config.CollisionSwitch
+			}
+			resolved, switchTo, err := resolveCollision(repo, cfg, result, policy, newNameGenerator(cfg, repo, branchType, args))
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error creating branch: %v\n", err)
 				os.Exit(1)
 			}
+			if resolved.Name != branchName {
+				fmt.Fprintf(os.Stderr, "Note: %s is taken, using %s\n", branchName, resolved.Name)
+				branchName = resolved.Name
+				result = resolved
+			}
+
+			var worktreePath string
+			if cmd.Flags().Changed("worktree") {
+				worktreePath, err = resolveWorktree(repo, worktree, result)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error resolving worktree path: %v\n", err)
+					os.Exit(1)
+				}
+			}
 
-			fmt.Printf("Created and switched to branch: %s\n", branchName)
+			if switchTo {
+				stashed := stashedChanges(repo, dirtyPolicy, worktreePath)
+				err := switchToExisting(repo, cfg, branchName, dirtyPolicy, worktreePath)
+				var restoreErr *git.RestoreError
+				if err != nil && !errors.As(err, &restoreErr) {
+					fmt.Fprintf(os.Stderr, "Error switching branch: %v\n", err)
+					os.Exit(1)
+				}
+				reportStashed(stashed)
+				if worktreePath != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "Checked out existing branch %s in worktree: %s\n", branchName, worktreePath)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "Switched to existing branch: %s\n", branchName)
+				}
+				reportRestore(restoreErr)
+
+				if push {
+					pushExisting(cmd.OutOrStdout(), repo, cfg, branchName)
+				}
+				return
+			}
+
+			base := from
+			if base == "" {
+				base = command.Base
+			}
+			if base == "" {
+				base = cfg.DefaultBase
+			}
+
+			stashed := stashedChanges(repo, dirtyPolicy, worktreePath)
+			opts := git.CreateOptions{
+				Base:        base,
+				Fetch:       fetchRef,
+				Strategy:    cfg.CreateStrategy,
+				Worktree:    worktreePath,
+				DirtyPolicy: dirtyPolicy,
+			}
+			err = repo.CreateBranch(branchName, opts)
+			var restoreErr *git.RestoreError
+			if err != nil && !errors.As(err, &restoreErr) {
+				fmt.Fprintf(os.Stderr, "Error creating branch: %v\n", err)
+				os.Exit(1)
+			}
+
+			reportStashed(stashed)
+
+			if worktreePath != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Created branch %s in worktree: %s\n", branchName, worktreePath)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Created and switched to branch: %s\n", branchName)
+			}
+			reportRestore(restoreErr)
+
+			if push {
+				remote := cfg.RemoteName()
+				if err := repo.Push(branchName, remote); err != nil {
+					fmt.Fprintf(os.Stderr, "Error pushing branch: %v\n", err)
+					fmt.Fprintf(os.Stderr, "The branch was created locally. If %s rejected the name, rename it with 'git branch -m' and push again.\n", remote)
+					os.Exit(1)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Pushed to %s and set upstream: %s/%s\n", remote, remote, branchName)
+			}
 		},
 	}
+
+	cmd.Flags().StringVar(&from, "from", "", "base ref to create the branch from (overrides default_base)")
+	cmd.Flags().BoolVar(&fetchRef, "fetch", false, "fetch the remote before creating the branch")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the branch name without creating it")
+	cmd.Flags().BoolVar(&dryRun, "print", false, "alias for --dry-run")
+	cmd.Flags().BoolVar(&stash, "stash", false, "stash uncommitted changes so the new branch starts clean")
+	cmd.Flags().BoolVar(&carry, "carry", false, "bring uncommitted changes over to the new branch")
+	cmd.Flags().BoolVar(&clean, "require-clean", false, "refuse to create the branch if there are uncommitted changes")
+	cmd.Flags().BoolVar(&move, "move", false, "stash uncommitted changes and re-apply them on the new branch")
+	cmd.MarkFlagsMutuallyExclusive("stash", "carry", "require-clean", "move")
+	cmd.Flags().BoolVar(&push, "push", cfg.AutoPush, "push the new branch to the remote and set its upstream (default from auto_push)")
+	cmd.Flags().StringVar(&policy, "on-collision", cfg.OnCollision, "what to do if the name is taken: error, switch or suffix")
+	cmd.Flags().BoolVar(&orSwitch, "or-switch", false, "switch to the branch if it already exists (same as --on-collision=switch)")
+	cmd.MarkFlagsMutuallyExclusive("or-switch", "on-collision")
+	cmd.Flags().StringVar(&worktree, "worktree", "", "create the branch in a new worktree at this path (use --worktree=<path>)")
+	// a bare --worktree uses the worktree_dir template
+	worktreeDir := cfg.WorktreeDir
+	if worktreeDir == "" {
+		worktreeDir = branch.DefaultWorktreeDir
+	}
+	cmd.Flags().Lookup("worktree").NoOptDefVal = worktreeDir
+
+	return cmd
 }
 
-func parseArgs(args []string, cfg *config.Config) (ticket string, description []string) {
+// resolveWorktree renders the worktree path template for a generated branch.
+// Relative paths are taken from the repository root.
+func resolveWorktree(repo git.Repository, tmpl string, result branch.Result) (string, error) {
+	root, err := repo.Root()
+	if err != nil {
+		return "", err
+	}
+	return branch.WorktreePath(tmpl, root, branch.WorktreeFields{
+		Fields: result.Fields,
+		Repo:   filepath.Base(root),
+		Branch: result.Name,
+	})
+}
+
+// pushExisting pushes a branch that was switched to rather than created, so
+// --push still gives it an upstream. A branch the remote already has is left
+// alone.
+func pushExisting(w io.Writer, repo git.Repository, cfg *config.Config, name string) {
+	remote := cfg.RemoteName()
+	branches, err := repo.ListBranches(true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing branches: %v\n", err)
+		os.Exit(1)
+	}
+	if slices.Contains(branches, git.Branch{Name: name, Remote: remote}) {
+		fmt.Fprintf(os.Stderr, "Note: %s is already on %s, not pushing\n", name, remote)
+		return
+	}
+
+	if err := repo.Push(name, remote); err != nil {
+		fmt.Fprintf(os.Stderr, "Error pushing branch: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(w, "Pushed to %s and set upstream: %s/%s\n", remote, remote, name)
+}
+
+// stashedChanges returns the changes the dirty policy is about to stash. It
+// is checked up front only to tell the user where their changes went.
+func stashedChanges(repo git.Repository, dirtyPolicy, worktreePath string) []string {
+	if dirtyPolicy != git.DirtyStash || worktreePath != "" {
+		return nil
+	}
+	stashed, _ := repo.Status()
+	return stashed
+}
+
+// reportStashed tells the user how to get their stashed changes back.
+func reportStashed(stashed []string) {
+	if len(stashed) > 0 {
+		fmt.Fprintf(os.Stderr, "Stashed %d uncommitted change(s), run 'git stash pop' to restore them\n", len(stashed))
+	}
+}
+
+// reportRestore warns that changes moved with --move could not be re-applied.
+// The branch was still created, so it isn't treated as a failure.
+func reportRestore(err *git.RestoreError) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Resolve any conflicts and run 'git stash drop', or run 'git stash pop' once the working tree is clean")
+	}
+}
+
+// generateName turns the command line arguments for a branch type into a
+// branch name using the configured template. A note is written to stderr if
+// the description had to be shortened to fit max_length.
+func generateName(cfg *config.Config, repo git.Repository, branchType string, args []string) (branch.Result, error) {
+	result, err := newNameGenerator(cfg, repo, branchType, args)("")
+	if err != nil {
+		return branch.Result{}, err
+	}
+
+	switch {
+	case result.Truncated && result.Fields.Slug == "":
+		fmt.Fprintf(os.Stderr, "Note: description dropped to fit max_length of %d\n", cfg.MaxLength)
+	case result.Truncated:
+		fmt.Fprintf(os.Stderr, "Note: description truncated to fit max_length of %d\n", cfg.MaxLength)
+	}
+	return result, nil
+}
+
+// nameGenerator generates the branch name for one set of arguments, with a
+// suffix that is kept within max_length.
+type nameGenerator func(suffix string) (branch.Result, error)
+
+func newNameGenerator(cfg *config.Config, repo git.Repository, branchType string, args []string) nameGenerator {
+	tickets, descParts := parseArgs(args, cfg)
+
+	// the user name is only used by templates, so a lookup failure is not fatal
+	user, _ := repo.Config("user.name")
+
+	ticketCase := cfg.TicketCase
+	keys := make([]string, 0, len(tickets))
+	for i, raw := range tickets {
+		ticket, _ := cfg.ParseTicket(raw)
+		if i == 0 {
+			ticketCase = ticket.Case
+		}
+		keys = append(keys, ticket.Key)
+	}
+
+	return func(suffix string) (branch.Result, error) {
+		return branch.Generate(branchType, strings.Join(keys, "-"), descParts, branch.Options{
+			Template:   cfg.BranchTemplate,
+			User:       user,
+			MaxLength:  cfg.MaxLength,
+			TicketCase: ticketCase,
+			Locale:     cfg.Transliterate,
+			Suffix:     suffix,
+		})
+	}
+}
+
+// parseName splits a branch name back into its fields using the configured
+// template, branch command types and ticket patterns. The ticket is returned
+// as its canonical key.
+func parseName(cfg *config.Config, name string) (branch.Parsed, error) {
+	parsed, err := branch.Parse(name, branch.ParseOptions{
+		Template:     cfg.BranchTemplate,
+		Types:        branchTypes(cfg),
+		IsTicket:     cfg.IsTicket,
+		IsBareTicket: cfg.IsBareTicket,
+	})
+	if err != nil {
+		return branch.Parsed{}, err
+	}
+
+	if ticket, ok := cfg.ParseTicket(parsed.Ticket); ok {
+		parsed.Ticket = ticket.Key
+	}
+	return parsed, nil
+}
+
+// branchTypes returns the branch type of every configured branch command.
+func branchTypes(cfg *config.Config) []string {
+	types := make([]string, 0, len(cfg.BranchCommands))
+	for _, command := range cfg.BranchCommands {
+		types = append(types, command.BranchType())
+	}
+	return types
+}
+
+// ticketPunctuation is stripped from around a word before checking whether it
+// is a ticket, so "PIP-99:" or "(#12)" are still recognised.
+const ticketPunctuation = `:;,.!?()[]{}<>"'`
+
+// parseArgs pulls ticket references out of the arguments wherever they
+// appear. Only the first ticket is taken unless multiple_tickets is enabled;
+// any others are left in the description.
+func parseArgs(args []string, cfg *config.Config) (tickets []string, description []string) {
 	if len(args) == 0 {
-		return "", nil
+		return nil, nil
 	}
 
-	first := args[0]
-	if cfg.IsTicket(first) {
-		return first, args[1:]
+	description = make([]string, 0, len(args))
+	for _, arg := range args {
+		candidate := strings.Trim(arg, ticketPunctuation)
+		if (len(tickets) == 0 || cfg.MultipleTickets) && cfg.IsTicket(candidate) {
+			tickets = append(tickets, candidate)
+			continue
+		}
+		description = append(description, arg)
 	}
 
-	return "", args
+	return tickets, description
 }
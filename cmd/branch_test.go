@@ -1,68 +1,111 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
 )
 
 func TestParseArgs(t *testing.T) {
 	cfg := config.Default()
+	multiCfg := config.Default()
+	multiCfg.MultipleTickets = true
 
 	tests := []struct {
 		name       string
 		args       []string
 		cfg        *config.Config
-		wantTicket string
+		wantTicket []string
 		wantDesc   []string
 	}{
 		{
 			name:       "with ticket at start",
 			args:       []string{"PIP-1234", "implement", "feature"},
 			cfg:        cfg,
-			wantTicket: "PIP-1234",
+			wantTicket: []string{"PIP-1234"},
 			wantDesc:   []string{"implement", "feature"},
 		},
 		{
 			name:       "with GitHub issue ticket",
 			args:       []string{"#123", "fix", "bug"},
 			cfg:        cfg,
-			wantTicket: "#123",
+			wantTicket: []string{"#123"},
 			wantDesc:   []string{"fix", "bug"},
 		},
 		{
 			name:       "without ticket",
 			args:       []string{"implement", "new", "feature"},
 			cfg:        cfg,
-			wantTicket: "",
+			wantTicket: nil,
 			wantDesc:   []string{"implement", "new", "feature"},
 		},
 		{
 			name:       "single word not a ticket",
 			args:       []string{"feature"},
 			cfg:        cfg,
-			wantTicket: "",
+			wantTicket: nil,
 			wantDesc:   []string{"feature"},
 		},
 		{
 			name:       "empty args",
 			args:       []string{},
 			cfg:        cfg,
-			wantTicket: "",
+			wantTicket: nil,
 			wantDesc:   nil,
 		},
 		{
 			name:       "ticket only",
 			args:       []string{"PIP-5678"},
 			cfg:        cfg,
-			wantTicket: "PIP-5678",
+			wantTicket: []string{"PIP-5678"},
 			wantDesc:   []string{},
 		},
+		{
+			name:       "ticket at end",
+			args:       []string{"fix", "login", "crash", "PIP-99"},
+			cfg:        cfg,
+			wantTicket: []string{"PIP-99"},
+			wantDesc:   []string{"fix", "login", "crash"},
+		},
+		{
+			name:       "ticket with punctuation",
+			args:       []string{"PIP-99:", "fix", "crash"},
+			cfg:        cfg,
+			wantTicket: []string{"PIP-99"},
+			wantDesc:   []string{"fix", "crash"},
+		},
+		{
+			name:       "ticket in brackets mid description",
+			args:       []string{"fix", "(#12)", "crash"},
+			cfg:        cfg,
+			wantTicket: []string{"#12"},
+			wantDesc:   []string{"fix", "crash"},
+		},
+		{
+			name:       "second ticket stays in description",
+			args:       []string{"PIP-1", "PIP-2", "fix"},
+			cfg:        cfg,
+			wantTicket: []string{"PIP-1"},
+			wantDesc:   []string{"PIP-2", "fix"},
+		},
+		{
+			name:       "multiple tickets when enabled",
+			args:       []string{"PIP-1", "fix", "PIP-2"},
+			cfg:        multiCfg,
+			wantTicket: []string{"PIP-1", "PIP-2"},
+			wantDesc:   []string{"fix"},
+		},
 		{
 			name:       "underscore ticket format",
 			args:       []string{"PIP_1234", "add", "tests"},
 			cfg:        cfg,
-			wantTicket: "PIP_1234",
+			wantTicket: []string{"PIP_1234"},
 			wantDesc:   []string{"add", "tests"},
 		},
 	}
@@ -71,8 +114,8 @@ func TestParseArgs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			gotTicket, gotDesc := parseArgs(tt.args, tt.cfg)
 
-			if gotTicket != tt.wantTicket {
-				t.Errorf("parseArgs() ticket = %q, want %q", gotTicket, tt.wantTicket)
+			if !slices.Equal(gotTicket, tt.wantTicket) {
+				t.Errorf("parseArgs() tickets = %q, want %q", gotTicket, tt.wantTicket)
 			}
 
 			if len(gotDesc) != len(tt.wantDesc) {
@@ -91,3 +134,399 @@ func TestParseArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestParseName(t *testing.T) {
+	cfg := config.Default()
+	cfg.BranchCommands = append(cfg.BranchCommands, config.BranchCommand{Name: "feature", Prefix: "feat/ui"})
+	// read numbers as GitHub issues
+	cfg.TicketPatterns[0].Bare = true
+
+	tests := []struct {
+		name       string
+		branch     string
+		wantType   string
+		wantTicket string
+		wantSlug   string
+		wantErr    bool
+	}{
+		{"type, ticket and description", "feat/pip-1234-add-login", "feat", "PIP-1234", "add-login", false},
+		{"github issue", "docs/456-update-guide", "docs", "#456", "update-guide", false},
+		{"no ticket", "fix/crash-on-start", "fix", "", "crash-on-start", false},
+		{"command prefix", "feat/ui/pip-1-button", "feat/ui", "PIP-1", "button", false},
+		{"unknown type", "wip/something", "", "", "", true},
+		{"no type", "main", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseName(cfg, tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseName(%q) error = %v, wantErr %v", tt.branch, err, tt.wantErr)
+			}
+			if got.Type != tt.wantType || got.Ticket != tt.wantTicket || got.Slug != tt.wantSlug {
+				t.Errorf("parseName(%q) = %+v, want %q, %q, %q", tt.branch, got, tt.wantType, tt.wantTicket, tt.wantSlug)
+			}
+		})
+	}
+}
+
+func TestBranchCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      func(cfg *config.Config)
+		args     []string
+		wantName string
+		wantBase string
+	}{
+		{
+			name:     "creates branch from current HEAD",
+			args:     []string{"feat", "PIP-1", "add", "login"},
+			wantName: "feat/pip-1-add-login",
+		},
+		{
+			name:     "from flag sets base",
+			args:     []string{"fix", "--from", "origin/main", "crash"},
+			wantName: "fix/crash",
+			wantBase: "origin/main",
+		},
+		{
+			name:     "default base from config",
+			cfg:      func(cfg *config.Config) { cfg.DefaultBase = "origin/main" },
+			args:     []string{"chore", "deps"},
+			wantName: "chore/deps",
+			wantBase: "origin/main",
+		},
+		{
+			name: "command base overrides default base",
+			cfg: func(cfg *config.Config) {
+				cfg.DefaultBase = "origin/main"
+				cfg.BranchCommands = []config.BranchCommand{{Name: "feat", Base: "develop"}}
+			},
+			args:     []string{"feat", "login"},
+			wantName: "feat/login",
+			wantBase: "develop",
+		},
+		{
+			name:     "user name from git config",
+			cfg:      func(cfg *config.Config) { cfg.BranchTemplate = "{{.User}}/{{.Type}}/{{.Slug}}" },
+			args:     []string{"feat", "login"},
+			wantName: "jane-doe/feat/login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+
+			repo := git.NewFake("develop")
+			repo.Remotes["origin"] = []string{"main"}
+			repo.Settings["user.name"] = "Jane Doe"
+
+			rootCmd := NewRootCmd(cfg, repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(tt.args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			if repo.Current != tt.wantName {
+				t.Errorf("current branch = %q, want %q", repo.Current, tt.wantName)
+			}
+			if repo.Bases[tt.wantName] != tt.wantBase {
+				t.Errorf("base = %q, want %q", repo.Bases[tt.wantName], tt.wantBase)
+			}
+			if !strings.Contains(out.String(), tt.wantName) {
+				t.Errorf("output = %q, should mention %q", out.String(), tt.wantName)
+			}
+		})
+	}
+
+	t.Run("fetch flag fetches", func(t *testing.T) {
+		repo := git.NewFake()
+		rootCmd := NewRootCmd(config.Default(), repo, "test")
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SetArgs([]string{"feat", "--fetch", "login"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		if repo.Fetches != 1 {
+			t.Errorf("Fetches = %d, want 1", repo.Fetches)
+		}
+	})
+
+	t.Run("create strategy from config", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.CreateStrategy = git.StrategySwitch
+		repo := git.NewFake()
+		rootCmd := NewRootCmd(cfg, repo, "test")
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SetArgs([]string{"feat", "login"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		if repo.Strategies["feat/login"] != git.StrategySwitch {
+			t.Errorf("strategy = %q, want switch", repo.Strategies["feat/login"])
+		}
+	})
+
+	t.Run("dirty policy", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			policy      string
+			args        []string
+			wantStashed bool
+		}{
+			{name: "carried by default", args: []string{"feat", "login"}},
+			{name: "stash flag", args: []string{"feat", "--stash", "login"}, wantStashed: true},
+			{name: "config default", policy: git.DirtyStash, args: []string{"feat", "login"}, wantStashed: true},
+			{name: "flag overrides config", policy: git.DirtyStash, args: []string{"feat", "--carry", "login"}},
+			{name: "move flag", args: []string{"feat", "--move", "login"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := config.Default()
+				cfg.DirtyPolicy = tt.policy
+				repo := git.NewFake()
+				repo.Dirty = []string{" M main.go"}
+
+				rootCmd := NewRootCmd(cfg, repo, "test")
+				rootCmd.SetOut(&bytes.Buffer{})
+				rootCmd.SetArgs(tt.args)
+				if err := rootCmd.Execute(); err != nil {
+					t.Fatalf("Execute() unexpected error: %v", err)
+				}
+
+				if stashed := len(repo.Stashes) == 1; stashed != tt.wantStashed {
+					t.Errorf("stashed = %v, want %v", stashed, tt.wantStashed)
+				}
+				if kept := len(repo.Dirty) == 1; kept == tt.wantStashed {
+					t.Errorf("changes kept in the working tree = %v, want %v", kept, !tt.wantStashed)
+				}
+				if repo.Current != "feat/login" {
+					t.Errorf("current branch = %q, want feat/login", repo.Current)
+				}
+			})
+		}
+
+		t.Run("moved changes that conflict", func(t *testing.T) {
+			repo := git.NewFake()
+			repo.Dirty = []string{" M main.go"}
+			repo.RestoreErr = errors.New("conflicts in main.go")
+
+			rootCmd := NewRootCmd(config.Default(), repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs([]string{"feat", "--move", "login"})
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			// the branch is still created, with the changes left in the stash
+			if repo.Current != "feat/login" || len(repo.Stashes) != 1 {
+				t.Errorf("current branch = %q with %d stash(es), want feat/login with the changes stashed", repo.Current, len(repo.Stashes))
+			}
+			if !strings.Contains(out.String(), "Created and switched to branch: feat/login") {
+				t.Errorf("output should report the new branch:\n%s", out.String())
+			}
+		})
+	})
+
+	t.Run("push", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			cfg      func(cfg *config.Config)
+			args     []string
+			wantPush string
+		}{
+			{name: "not pushed by default", args: []string{"feat", "login"}},
+			{name: "push flag", args: []string{"feat", "--push", "login"}, wantPush: "origin"},
+			{name: "auto_push", cfg: func(cfg *config.Config) { cfg.AutoPush = true }, args: []string{"feat", "login"}, wantPush: "origin"},
+			{name: "flag overrides auto_push", cfg: func(cfg *config.Config) { cfg.AutoPush = true }, args: []string{"feat", "--push=false", "login"}},
+			{name: "configured remote", cfg: func(cfg *config.Config) { cfg.Remote = "upstream" }, args: []string{"feat", "--push", "login"}, wantPush: "upstream"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := config.Default()
+				if tt.cfg != nil {
+					tt.cfg(cfg)
+				}
+				repo := git.NewFake()
+				repo.Remotes["origin"] = nil
+				repo.Remotes["upstream"] = nil
+
+				rootCmd := NewRootCmd(cfg, repo, "test")
+				var out bytes.Buffer
+				rootCmd.SetOut(&out)
+				rootCmd.SetArgs(tt.args)
+				if err := rootCmd.Execute(); err != nil {
+					t.Fatalf("Execute() unexpected error: %v", err)
+				}
+
+				if got := repo.Upstreams["feat/login"]; got != tt.wantPush {
+					t.Errorf("upstream = %q, want %q", got, tt.wantPush)
+				}
+				if pushed := strings.Contains(out.String(), "Pushed"); pushed != (tt.wantPush != "") {
+					t.Errorf("output = %q", out.String())
+				}
+			})
+		}
+	})
+
+	t.Run("collision suffix", func(t *testing.T) {
+		repo := git.NewFake("feat/login")
+		rootCmd := NewRootCmd(config.Default(), repo, "test")
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SetArgs([]string{"feat", "--on-collision", "suffix", "login"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		if repo.Current != "feat/login-2" {
+			t.Errorf("current branch = %q, want feat/login-2", repo.Current)
+		}
+	})
+
+	t.Run("collision switch", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.OnCollision = config.CollisionSwitch
+		repo := git.NewFake("feat/login")
+		rootCmd := NewRootCmd(cfg, repo, "test")
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"feat", "login"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		if repo.Current != "feat/login" || !strings.Contains(out.String(), "existing") {
+			t.Errorf("should switch to the existing branch, got %q: %q", repo.Current, out.String())
+		}
+	})
+
+	t.Run("or-switch is idempotent", func(t *testing.T) {
+		repo := git.NewFake()
+		repo.Remotes["origin"] = []string{"feat/pip-12-add-login"}
+
+		// the first run picks up the branch a colleague pushed, the second
+		// finds the local branch the first one created
+		for i := 0; i < 2; i++ {
+			if err := repo.Checkout("main", git.CheckoutOptions{}); err != nil {
+				t.Fatalf("Checkout() unexpected error: %v", err)
+			}
+			rootCmd := NewRootCmd(config.Default(), repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs([]string{"feat", "--or-switch", "PIP-12", "add", "login"})
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if repo.Current != "feat/pip-12-add-login" {
+				t.Errorf("run %d: current branch = %q", i+1, repo.Current)
+			}
+		}
+		if repo.Upstreams["feat/pip-12-add-login"] != "origin" {
+			t.Error("branch should track the remote branch")
+		}
+	})
+
+	t.Run("or-switch pushes an existing branch", func(t *testing.T) {
+		repo := git.NewFake("feat/login", "feat/shared")
+		repo.Remotes["origin"] = []string{"feat/shared"}
+
+		for _, name := range []string{"login", "shared"} {
+			rootCmd := NewRootCmd(config.Default(), repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs([]string{"feat", "--or-switch", "--push", name})
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if pushed := strings.Contains(out.String(), "Pushed"); pushed != (name == "login") {
+				t.Errorf("feat/%s pushed = %v, only a branch the remote doesn't have should be pushed: %q", name, pushed, out.String())
+			}
+		}
+		if repo.Upstreams["feat/login"] != "origin" {
+			t.Errorf("upstream = %q, want origin", repo.Upstreams["feat/login"])
+		}
+	})
+
+	t.Run("or-switch opens an existing branch in a worktree", func(t *testing.T) {
+		repo := git.NewFake("feat/login")
+		rootCmd := NewRootCmd(config.Default(), repo, "test")
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"feat", "--or-switch", "--worktree=/tmp/login", "login"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		if repo.Current != "main" {
+			t.Errorf("current branch = %q, a worktree should leave it alone", repo.Current)
+		}
+		if got := repo.Worktrees["feat/login"]; got != filepath.FromSlash("/tmp/login") {
+			t.Errorf("worktree = %q, want /tmp/login", got)
+		}
+		if !strings.Contains(out.String(), "worktree") {
+			t.Errorf("output = %q, should mention the worktree", out.String())
+		}
+	})
+
+	worktreeTests := []struct {
+		name     string
+		cfg      func(cfg *config.Config)
+		args     []string
+		wantPath string
+	}{
+		{
+			name:     "bare worktree flag uses the default template",
+			args:     []string{"feat", "--worktree", "PIP-1", "add", "login"},
+			wantPath: "/src/repo-add-login",
+		},
+		{
+			name:     "bare worktree flag uses worktree_dir",
+			cfg:      func(cfg *config.Config) { cfg.WorktreeDir = "../worktrees/{{.Branch}}" },
+			args:     []string{"feat", "--worktree", "login"},
+			wantPath: "/src/worktrees/feat/login",
+		},
+		{
+			name:     "explicit worktree path",
+			args:     []string{"fix", "--worktree=/tmp/crash", "crash"},
+			wantPath: "/tmp/crash",
+		},
+	}
+
+	for _, tt := range worktreeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+
+			repo := git.NewFake()
+			rootCmd := NewRootCmd(cfg, repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(tt.args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			if repo.Current != "main" {
+				t.Errorf("current branch = %q, a worktree should leave it alone", repo.Current)
+			}
+			var got string
+			for _, path := range repo.Worktrees {
+				got = path
+			}
+			if got != filepath.FromSlash(tt.wantPath) {
+				t.Errorf("worktree = %q, want %q", got, tt.wantPath)
+			}
+			if !strings.Contains(out.String(), "worktree") {
+				t.Errorf("output = %q, should mention the worktree", out.String())
+			}
+		})
+	}
+}
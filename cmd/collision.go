@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/owenrumney/branch/internal/branch"
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+// maxSuffix bounds the search for a free suffixed name.
+const maxSuffix = 100
+
+// taken reports where a branch name is already in use: "local", a
+// remote-tracking ref such as "origin/feat/x", the remote itself if
+// check_remote asks it directly, or an empty string if the name is free.
+func taken(repo git.Repository, cfg *config.Config, name string, remoteRefs []string) (string, error) {
+	branches, err := repo.ListBranches(true)
+	if err != nil {
+		return "", err
+	}
+
+	remote := cfg.RemoteName()
+	for _, b := range branches {
+		if b.Name != name {
+			continue
+		}
+		if b.Remote == "" {
+			return "local", nil
+		}
+		if b.Remote == remote {
+			return b.FullName(), nil
+		}
+	}
+
+	if slices.Contains(remoteRefs, name) {
+		return remote, nil
+	}
+	return "", nil
+}
+
+// resolveCollision checks whether the generated name is already in use
+// locally or on the configured remote, and applies the on_collision policy
+// if it is. It returns the branch to create, or switch set if the existing
+// branch should be checked out instead. Suffixed names are made with
+// generate, so they still fit in max_length.
+func resolveCollision(repo git.Repository, cfg *config.Config, result branch.Result, policy string, generate nameGenerator) (resolved branch.Result, switchTo bool, err error) {
+	name := result.Name
+
+	var remoteRefs []string
+	if cfg.CheckRemote {
+		// asking the remote catches branches that haven't been fetched yet
+		remoteRefs, err = repo.RemoteRefs(cfg.RemoteName())
+		if err != nil {
+			return branch.Result{}, false, fmt.Errorf("checking %s for existing branches: %w", cfg.RemoteName(), err)
+		}
+	}
+
+	where, err := taken(repo, cfg, name, remoteRefs)
+	if err != nil || where == "" {
+		return result, false, err
+	}
+
+	switch policy {
+	case "", config.CollisionError:
+		return branch.Result{}, false, fmt.Errorf("branch %q already exists (%s)", name, where)
+	case config.CollisionSwitch:
+		return result, true, nil
+	case config.CollisionSuffix:
+		suffix := cfg.CollisionSuffix
+		if suffix == "" {
+			suffix = config.DefaultCollisionSuffix
+		}
+		for n := 2; n <= maxSuffix; n++ {
+			candidate, err := generate(fmt.Sprintf(suffix, n))
+			if err != nil {
+				return branch.Result{}, false, err
+			}
+			where, err := taken(repo, cfg, candidate.Name, remoteRefs)
+			if err != nil {
+				return branch.Result{}, false, err
+			}
+			if where == "" {
+				return candidate, false, nil
+			}
+		}
+		return branch.Result{}, false, fmt.Errorf("no free name for %q after %d attempts", name, maxSuffix)
+	default:
+		return branch.Result{}, false, fmt.Errorf("unknown collision policy %q", policy)
+	}
+}
+
+// switchToExisting checks out a branch that already exists locally, or
+// creates a local branch tracking it if it only exists on the remote.
+// Uncommitted changes are handled by dirtyPolicy, as they are for a new branch.
+// If worktreePath is set, the branch is checked out there instead.
+func switchToExisting(repo git.Repository, cfg *config.Config, name, dirtyPolicy, worktreePath string) error {
+	exists, err := repo.BranchExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return repo.Checkout(name, git.CheckoutOptions{Strategy: cfg.CreateStrategy, Worktree: worktreePath, DirtyPolicy: dirtyPolicy})
+	}
+
+	base := cfg.RemoteName() + "/" + name
+	branches, err := repo.ListBranches(true)
+	if err != nil {
+		return err
+	}
+	fetched := slices.ContainsFunc(branches, func(b git.Branch) bool { return b.FullName() == base })
+
+	// start from the remote branch, fetching it first if it was only seen on the remote
+	return repo.CreateBranch(name, git.CreateOptions{
+		Base:        base,
+		Track:       true,
+		Fetch:       !fetched,
+		Strategy:    cfg.CreateStrategy,
+		Worktree:    worktreePath,
+		DirtyPolicy: dirtyPolicy,
+	})
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/branch"
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestResolveCollision(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        func(cfg *config.Config)
+		branch     string
+		policy     string
+		want       string
+		wantSwitch bool
+		wantErr    bool
+	}{
+		{
+			name:   "free name is kept",
+			branch: "feat/new",
+			want:   "feat/new",
+		},
+		{
+			name:    "local branch is an error by default",
+			branch:  "feat/local",
+			wantErr: true,
+		},
+		{
+			name:    "remote-tracking branch is an error",
+			branch:  "feat/tracked",
+			policy:  config.CollisionError,
+			wantErr: true,
+		},
+		{
+			name:   "branch on another remote is not a collision",
+			branch: "feat/upstream",
+			want:   "feat/upstream",
+		},
+		{
+			name:   "unfetched remote branch is free without check_remote",
+			branch: "feat/unfetched",
+			want:   "feat/unfetched",
+		},
+		{
+			name:    "check_remote asks the remote",
+			cfg:     func(cfg *config.Config) { cfg.CheckRemote = true },
+			branch:  "feat/unfetched",
+			wantErr: true,
+		},
+		{
+			name:       "switch to the existing branch",
+			branch:     "feat/tracked",
+			policy:     config.CollisionSwitch,
+			want:       "feat/tracked",
+			wantSwitch: true,
+		},
+		{
+			name:   "suffix skips names that are also taken",
+			branch: "feat/local",
+			policy: config.CollisionSuffix,
+			want:   "feat/local-3",
+		},
+		{
+			name:   "custom suffix",
+			cfg:    func(cfg *config.Config) { cfg.CollisionSuffix = "-v%d" },
+			branch: "feat/local",
+			policy: config.CollisionSuffix,
+			want:   "feat/local-v2",
+		},
+		{
+			name:   "configured remote",
+			cfg:    func(cfg *config.Config) { cfg.Remote = "upstream" },
+			branch: "feat/upstream",
+			policy: config.CollisionSuffix,
+			want:   "feat/upstream-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+
+			repo := git.NewFake("feat/local", "feat/local-2")
+			repo.Remotes["origin"] = []string{"main", "feat/tracked", "feat/unfetched"}
+			repo.Remotes["upstream"] = []string{"feat/upstream"}
+			// the fake lists every remote branch as fetched, so model an
+			// unfetched one by hiding it from the remote-tracking refs
+			fake := &unfetched{Fake: repo, hidden: "origin/feat/unfetched"}
+
+			generate := func(suffix string) (branch.Result, error) {
+				return branch.Result{Name: tt.branch + suffix}, nil
+			}
+
+			got, switchTo, err := resolveCollision(fake, cfg, branch.Result{Name: tt.branch}, tt.policy, generate)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveCollision() = %q, want error", got.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveCollision() unexpected error: %v", err)
+			}
+			if got.Name != tt.want || switchTo != tt.wantSwitch {
+				t.Errorf("resolveCollision() = %q, %v, want %q, %v", got.Name, switchTo, tt.want, tt.wantSwitch)
+			}
+		})
+	}
+}
+
+func TestResolveCollisionMaxLength(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxLength = 19
+	repo := git.NewFake("feat/add-login-page")
+
+	args := []string{"add", "login", "page"}
+	result, err := generateName(cfg, repo, "feat", args)
+	if err != nil {
+		t.Fatalf("generateName() unexpected error: %v", err)
+	}
+
+	got, _, err := resolveCollision(repo, cfg, result, config.CollisionSuffix, newNameGenerator(cfg, repo, "feat", args))
+	if err != nil {
+		t.Fatalf("resolveCollision() unexpected error: %v", err)
+	}
+	// the description is shortened to make room for the suffix
+	if got.Name != "feat/add-login-2" || got.Fields.Slug != "add-login" {
+		t.Errorf("resolveCollision() = %q (slug %q), want feat/add-login-2", got.Name, got.Fields.Slug)
+	}
+}
+
+func TestSwitchToExisting(t *testing.T) {
+	repo := git.NewFake("feat/local")
+	repo.Remotes["origin"] = []string{"feat/tracked"}
+
+	if err := switchToExisting(repo, config.Default(), "feat/local", "", ""); err != nil {
+		t.Fatalf("switchToExisting() unexpected error: %v", err)
+	}
+	if repo.Current != "feat/local" {
+		t.Errorf("current branch = %q, want feat/local", repo.Current)
+	}
+
+	if err := switchToExisting(repo, config.Default(), "feat/tracked", "", ""); err != nil {
+		t.Fatalf("switchToExisting() unexpected error: %v", err)
+	}
+	if repo.Current != "feat/tracked" || repo.Bases["feat/tracked"] != "origin/feat/tracked" {
+		t.Errorf("remote branch should be checked out from origin, got %q from %q", repo.Current, repo.Bases["feat/tracked"])
+	}
+	if repo.Upstreams["feat/tracked"] != "origin" {
+		t.Errorf("upstream = %q, the local branch should track origin", repo.Upstreams["feat/tracked"])
+	}
+
+	repo.Remotes["origin"] = append(repo.Remotes["origin"], "feat/elsewhere")
+	if err := switchToExisting(repo, config.Default(), "feat/elsewhere", "", "/tmp/elsewhere"); err != nil {
+		t.Fatalf("switchToExisting() unexpected error: %v", err)
+	}
+	if repo.Current != "feat/tracked" || repo.Worktrees["feat/elsewhere"] != "/tmp/elsewhere" {
+		t.Errorf("remote branch should be checked out in the worktree, got %q in %q", repo.Current, repo.Worktrees["feat/elsewhere"])
+	}
+}
+
+func TestSwitchToExistingDirty(t *testing.T) {
+	tests := []struct {
+		name        string
+		branch      string
+		policy      string
+		wantErr     bool
+		wantStashed bool
+	}{
+		{name: "local branch carries changes", branch: "feat/local"},
+		{name: "local branch stashes changes", branch: "feat/local", policy: git.DirtyStash, wantStashed: true},
+		{name: "local branch requires clean tree", branch: "feat/local", policy: git.DirtyRequireClean, wantErr: true},
+		{name: "remote branch stashes changes", branch: "feat/tracked", policy: git.DirtyStash, wantStashed: true},
+		{name: "remote branch requires clean tree", branch: "feat/tracked", policy: git.DirtyRequireClean, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.CreateStrategy = git.StrategySwitch
+			repo := git.NewFake("feat/local")
+			repo.Remotes["origin"] = []string{"feat/tracked"}
+			repo.Dirty = []string{" M main.go"}
+
+			err := switchToExisting(repo, cfg, tt.branch, tt.policy, "")
+			if tt.wantErr {
+				var dirty *git.DirtyError
+				if !errors.As(err, &dirty) {
+					t.Fatalf("switchToExisting() error = %v, want DirtyError", err)
+				}
+				if repo.Current != "main" {
+					t.Errorf("current branch = %q, should stay on main", repo.Current)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("switchToExisting() unexpected error: %v", err)
+			}
+			if repo.Current != tt.branch {
+				t.Errorf("current branch = %q, want %q", repo.Current, tt.branch)
+			}
+			if stashed := len(repo.Stashes) == 1; stashed != tt.wantStashed {
+				t.Errorf("stashed = %v, want %v", stashed, tt.wantStashed)
+			}
+			if strategy, ok := repo.Strategies[tt.branch]; ok && strategy != git.StrategySwitch {
+				t.Errorf("strategy = %q, want create_strategy to be used", strategy)
+			}
+		})
+	}
+}
+
+// unfetched hides a remote-tracking ref from a Fake, as if it had not been
+// fetched, while the remote still reports it.
+type unfetched struct {
+	*git.Fake
+	hidden string
+}
+
+func (u *unfetched) ListBranches(remote bool) ([]git.Branch, error) {
+	branches, err := u.Fake.ListBranches(remote)
+	var visible []git.Branch
+	for _, b := range branches {
+		if b.FullName() != u.hidden {
+			visible = append(visible, b)
+		}
+	}
+	return visible, err
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd(repo git.Repository) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "View, edit and check the branch configuration",
+	}
+
+	configCmd.AddCommand(newConfigPathCmd())
+	configCmd.AddCommand(newConfigShowCmd(repo))
+	configCmd.AddCommand(newConfigInitCmd())
+	configCmd.AddCommand(newConfigValidateCmd(repo))
+	configCmd.AddCommand(newConfigEditCmd("add-command <name>", "Add a branch command to the user config", addCommand))
+	configCmd.AddCommand(newConfigEditCmd("remove-command <name>", "Remove a branch command from the user config", removeCommand))
+	configCmd.AddCommand(newConfigEditCmd("add-pattern <regex>", "Add a ticket pattern to the user config", addPattern))
+	configCmd.AddCommand(newConfigEditCmd("remove-pattern <regex>", "Remove a ticket pattern from the user config", removePattern))
+	return configCmd
+}
+
+func newConfigPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the location of the user config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			configPath, err := config.Path()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding config path: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), configPath)
+		},
+	}
+}
+
+func newConfigShowCmd(repo git.Repository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration",
+		Long: `Print the effective configuration after the user and repository config files
+have been merged over the defaults. The files that were used are listed first as
+comment lines starting with '#'.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(repo)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := showConfig(cmd.OutOrStdout(), cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error showing config: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func showConfig(w io.Writer, cfg *config.Config) error {
+	sources := cfg.Sources()
+	if len(sources) == 0 {
+		fmt.Fprintln(w, "# source: defaults (no config files found)")
+	}
+	for _, source := range sources {
+		fmt.Fprintf(w, "# source: %s\n", source)
+	}
+
+	data, err := cfg.JSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func newConfigInitCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write the default configuration to the user config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			configPath, err := config.Path()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding config path: %v\n", err)
+				os.Exit(1)
+			}
+
+			if _, err := os.Stat(configPath); err == nil && !force {
+				fmt.Fprintf(os.Stderr, "Error: %s already exists, use --force to overwrite it\n", configPath)
+				os.Exit(1)
+			}
+
+			if err := config.Default().Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote default config to %s\n", configPath)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config file")
+	return cmd
+}
+
+// newConfigEditCmd builds a command that applies edit to the user config and
+// writes back the setting it changed. The repository config is never modified.
+func newConfigEditCmd(use, short string, edit func(cfg *config.Config, value string) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.EditUser(func(cfg *config.Config) error {
+				return edit(cfg, args[0])
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error updating config: %v\n", err)
+				os.Exit(1)
+			}
+			if path, err := config.Path(); err == nil && !config.KeepsComments(path) {
+				fmt.Fprintf(os.Stderr, "Warning: %s was written back from its settings, so any comments in it are gone\n", path)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Config updated")
+		},
+	}
+}
+
+func addCommand(cfg *config.Config, name string) error {
+	if _, ok := cfg.FindCommand(name); ok {
+		return fmt.Errorf("branch command %q already exists", name)
+	}
+	cfg.BranchCommands = append(cfg.BranchCommands, config.BranchCommand{Name: name})
+	return nil
+}
+
+func removeCommand(cfg *config.Config, name string) error {
+	i := slices.Index(cfg.CommandNames(), name)
+	if i < 0 {
+		return fmt.Errorf("branch command %q not found", name)
+	}
+	cfg.BranchCommands = slices.Delete(cfg.BranchCommands, i, i+1)
+	return nil
+}
+
+func addPattern(cfg *config.Config, pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+	for _, existing := range cfg.TicketPatterns {
+		if existing.Pattern == pattern {
+			return fmt.Errorf("ticket pattern %q already exists", pattern)
+		}
+	}
+	cfg.TicketPatterns = append(cfg.TicketPatterns, config.TicketPattern{Pattern: pattern})
+	return nil
+}
+
+func removePattern(cfg *config.Config, pattern string) error {
+	i := slices.IndexFunc(cfg.TicketPatterns, func(p config.TicketPattern) bool {
+		return p.Pattern == pattern
+	})
+	if i < 0 {
+		return fmt.Errorf("ticket pattern %q not found", pattern)
+	}
+	cfg.TicketPatterns = slices.Delete(cfg.TicketPatterns, i, i+1)
+	return nil
+}
+
+func newConfigValidateCmd(repo git.Repository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration for problems",
+		Long: `Check the configuration for problems such as invalid ticket regular expressions,
+empty or duplicate branch commands, and commands that collide with built-in commands.
+
+Each problem is printed with the config key it was found under. The exit code is
+non-zero if any problems are found.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(repo)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !printProblems(cmd.OutOrStdout(), cfg.Problems()) {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// printProblems writes each config problem on its own line, or a
+// confirmation if there are none. It reports whether the config is valid.
+func printProblems(w io.Writer, problems []config.Problem) bool {
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "Config is valid")
+		return true
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/owenrumney/branch/internal/testutil"
+)
+
+func TestPrintProblems(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		var out bytes.Buffer
+		if !printProblems(&out, nil) {
+			t.Error("printProblems() should report a valid config")
+		}
+		if out.String() != "Config is valid\n" {
+			t.Errorf("output = %q", out.String())
+		}
+	})
+
+	t.Run("lists problems", func(t *testing.T) {
+		var out bytes.Buffer
+		problems := []config.Problem{
+			{Key: "ticket_patterns[1]", Message: "invalid regular expression"},
+			{Key: "branch_commands[0]", Message: `duplicate command "feat"`},
+		}
+		if printProblems(&out, problems) {
+			t.Error("printProblems() should report an invalid config")
+		}
+		want := "ticket_patterns[1]: invalid regular expression\nbranch_commands[0]: duplicate command \"feat\"\n"
+		if out.String() != want {
+			t.Errorf("output = %q, want %q", out.String(), want)
+		}
+	})
+}
+
+func TestConfigEditCommands(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	testDir := t.TempDir()
+	oldEnv := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", testDir)
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		rootCmd := NewRootCmd(config.Default(), git.NewFake(), "test")
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute(%v) unexpected error: %v", args, err)
+		}
+		return out.String()
+	}
+
+	t.Run("path", func(t *testing.T) {
+		want := filepath.Join(testDir, "branch", "config.json")
+		if got := strings.TrimSpace(run(t, "config", "path")); got != want {
+			t.Errorf("config path = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("init writes defaults", func(t *testing.T) {
+		run(t, "config", "init")
+		data, err := os.ReadFile(filepath.Join(testDir, "branch", "config.json"))
+		if err != nil {
+			t.Fatalf("config init should create the config file: %v", err)
+		}
+		if !strings.Contains(string(data), "(?P<number>") {
+			t.Errorf("named groups should be written without escaping:\n%s", data)
+		}
+	})
+
+	t.Run("add and remove command", func(t *testing.T) {
+		run(t, "config", "add-command", "hotfix")
+		run(t, "config", "remove-command", "docs")
+
+		cfg, err := config.LoadUser()
+		if err != nil {
+			t.Fatalf("LoadUser() unexpected error: %v", err)
+		}
+		if !slices.Contains(cfg.CommandNames(), "hotfix") {
+			t.Errorf("Expected hotfix to be added, got %v", cfg.BranchCommands)
+		}
+		if slices.Contains(cfg.CommandNames(), "docs") {
+			t.Errorf("Expected docs to be removed, got %v", cfg.BranchCommands)
+		}
+	})
+
+	t.Run("add and remove pattern", func(t *testing.T) {
+		run(t, "config", "add-pattern", `^ops#\d+$`)
+
+		cfg, err := config.LoadUser()
+		if err != nil {
+			t.Fatalf("LoadUser() unexpected error: %v", err)
+		}
+		if !cfg.IsTicket("ops#1") {
+			t.Error("Expected added pattern to match ops#1")
+		}
+
+		run(t, "config", "remove-pattern", `^ops#\d+$`)
+		cfg, err = config.LoadUser()
+		if err != nil {
+			t.Fatalf("LoadUser() unexpected error: %v", err)
+		}
+		if cfg.IsTicket("ops#1") {
+			t.Error("Expected removed pattern not to match ops#1")
+		}
+	})
+
+	t.Run("show lists source", func(t *testing.T) {
+		out := run(t, "config", "show")
+		if !strings.Contains(out, "# source: "+filepath.Join(testDir, "branch", "config.json")) {
+			t.Errorf("config show should list the config source, got %q", out)
+		}
+		if strings.Contains(out, `\u003c`) {
+			t.Errorf("config show should not escape < and >, got %q", out)
+		}
+		if !strings.Contains(out, `"hotfix"`) {
+			t.Errorf("config show should print the effective config, got %q", out)
+		}
+	})
+}
+
+func TestConfigEditFuncs(t *testing.T) {
+	cfg := config.Default()
+
+	if err := addCommand(cfg, "feat"); err == nil {
+		t.Error("addCommand() should reject a duplicate command")
+	}
+	if err := removeCommand(cfg, "missing"); err == nil {
+		t.Error("removeCommand() should reject an unknown command")
+	}
+	if err := addPattern(cfg, "invalid[regex"); err == nil {
+		t.Error("addPattern() should reject an invalid regular expression")
+	}
+	if err := removePattern(cfg, "missing"); err == nil {
+		t.Error("removePattern() should reject an unknown pattern")
+	}
+}
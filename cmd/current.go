@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// currentBranch is the current branch as printed by current --json.
+type currentBranch struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Ticket string `json:"ticket,omitempty"`
+	// TicketInferred marks a ticket read from a bare number, e.g. #456 from
+	// docs/456-update-guide.
+	TicketInferred bool   `json:"ticket_inferred,omitempty"`
+	Slug           string `json:"slug,omitempty"`
+	User           string `json:"user,omitempty"`
+	Date           string `json:"date,omitempty"`
+}
+
+func newCurrentCmd(cfg *config.Config, repo git.Repository) *cobra.Command {
+	var ticket, strict, branchType, slug, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "current",
+		Short: "Print the current branch, or a field parsed from its name",
+		Long: `Print the current branch name, or one of the fields it was generated from, for shell prompts, commit hooks and CI scripts.
+
+The name is split up using the branch commands, ticket patterns and branch_template from the config.
+When the requested field is missing, nothing is printed and the exit status is 1.
+With --strict, a ticket read from a bare number, such as #456 from docs/456-update-guide, counts as missing.
+
+Examples:
+  branch current --ticket    ->  PIP-1234
+  branch current --json      ->  {"name":"feat/pip-1234-add-login","type":"feat","ticket":"PIP-1234","slug":"add-login"}`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, err := repo.CurrentBranch()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading current branch: %v\n", err)
+				os.Exit(1)
+			}
+
+			// a name that doesn't follow the convention just has no fields
+			parsed, _ := parseName(cfg, name)
+
+			var value string
+			switch {
+			case asJSON:
+				out, err := json.Marshal(currentBranch{
+					Name:           name,
+					Type:           parsed.Type,
+					Ticket:         parsed.Ticket,
+					TicketInferred: parsed.TicketInferred,
+					Slug:           parsed.Slug,
+					User:           parsed.User,
+					Date:           parsed.Date,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error encoding branch: %v\n", err)
+					os.Exit(1)
+				}
+				value = string(out)
+			case ticket && strict && parsed.TicketInferred:
+				// only a guess, so not good enough for e.g. a commit hook
+			case ticket:
+				value = parsed.Ticket
+			case branchType:
+				value = parsed.Type
+			case slug:
+				value = parsed.Slug
+			default:
+				value = name
+			}
+
+			if value == "" {
+				os.Exit(1)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+		},
+	}
+
+	cmd.Flags().BoolVar(&ticket, "ticket", false, "print the ticket, e.g. PIP-1234")
+	cmd.Flags().BoolVar(&strict, "strict", false, "with --ticket, ignore a ticket read from a bare number")
+	cmd.Flags().BoolVar(&branchType, "type", false, "print the branch type")
+	cmd.Flags().BoolVar(&slug, "slug", false, "print the description slug")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the name and every field as JSON")
+	cmd.MarkFlagsMutuallyExclusive("ticket", "type", "slug", "json")
+
+	return cmd
+}
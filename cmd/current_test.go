@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestCurrentCmd(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     func(cfg *config.Config)
+		current string
+		args    []string
+		want    string
+	}{
+		{
+			name:    "branch name",
+			current: "feat/pip-1234-add-login",
+			args:    []string{"current"},
+			want:    "feat/pip-1234-add-login",
+		},
+		{
+			name:    "ticket",
+			current: "feat/pip-1234-add-login",
+			args:    []string{"current", "--ticket"},
+			want:    "PIP-1234",
+		},
+		{
+			name:    "type and slug",
+			current: "fix/crash-on-start",
+			args:    []string{"current", "--slug"},
+			want:    "crash-on-start",
+		},
+		{
+			name:    "custom template",
+			cfg:     func(cfg *config.Config) { cfg.BranchTemplate = "{{.User}}/{{upper .Ticket}}/{{.Slug}}" },
+			current: "jane/OPS-12/rotate-keys",
+			args:    []string{"current", "--ticket"},
+			want:    "OPS-12",
+		},
+		{
+			name:    "leading number is not a ticket",
+			current: "chore/2024-cleanup",
+			args:    []string{"current", "--json"},
+			want:    `{"name":"chore/2024-cleanup","type":"chore","slug":"2024-cleanup"}`,
+		},
+		{
+			name:    "json",
+			cfg:     func(cfg *config.Config) { cfg.TicketPatterns[0].Bare = true },
+			current: "docs/456-update-guide",
+			args:    []string{"current", "--json"},
+			want:    `{"name":"docs/456-update-guide","type":"docs","ticket":"#456","ticket_inferred":true,"slug":"update-guide"}`,
+		},
+		{
+			name:    "strict ticket",
+			current: "feat/pip-1234-add-login",
+			args:    []string{"current", "--ticket", "--strict"},
+			want:    "PIP-1234",
+		},
+		{
+			name:    "json for a name outside the convention",
+			current: "main",
+			args:    []string{"current", "--json"},
+			want:    `{"name":"main"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+			repo := git.NewFake(tt.current)
+			repo.Current = tt.current
+
+			rootCmd := NewRootCmd(cfg, repo, "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(tt.args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// hookMarker is written into every hook branch installs, so uninstall and
+// reinstalling never touch hooks that came from somewhere else.
+const hookMarker = "# Installed by branch, remove with: branch hooks uninstall"
+
+// prePushHook checks the names of the branches being pushed. Deletions and
+// tags don't have a refs/heads/ local ref, so they are skipped.
+const prePushHook = `#!/bin/sh
+` + hookMarker + `
+#
+# Checks the names of the branches being pushed with branch lint.
+
+if ! command -v branch >/dev/null 2>&1; then
+	echo "branch is not on the PATH, skipping the branch name check" >&2
+	exit 0
+fi
+
+while read -r local_ref local_oid remote_ref remote_oid; do
+	case "$local_ref" in
+	refs/heads/*) echo "${local_ref#refs/heads/}" ;;
+	esac
+done | branch lint -
+`
+
+// commitMsgHook prefixes the commit message with the ticket from the branch
+// name. Empty messages are left alone so git still aborts the commit.
+const commitMsgHook = `#!/bin/sh
+` + hookMarker + `
+#
+# Prefixes the commit message with the ticket from the branch name, e.g. "PIP-1234: ".
+
+command -v branch >/dev/null 2>&1 || exit 0
+
+# no ticket in the branch name, only a number that may not be one, or a detached HEAD
+ticket=$(branch current --ticket --strict 2>/dev/null) || exit 0
+
+message=$(grep -v '^#' "$1")
+printf '%s' "$message" | grep -q '[^[:space:]]' || exit 0
+printf '%s' "$message" | grep -qiF -e "$ticket" && exit 0
+
+{ printf '%s: ' "$ticket"; cat "$1"; } >"$1.tmp" && mv "$1.tmp" "$1"
+`
+
+// hooks are the hooks branch installs, in the order they are reported.
+var hooks = []struct {
+	name   string
+	script string
+}{
+	{"commit-msg", commitMsgHook},
+	{"pre-push", prePushHook},
+}
+
+func newHooksCmd(repo git.Repository) *cobra.Command {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Install git hooks that enforce the naming convention",
+		Long: `Install or remove git hooks in the current repository:
+
+  pre-push    runs branch lint on the branches being pushed
+  commit-msg  prefixes commit messages with the ticket from the branch name, e.g. "PIP-1234: "
+
+Hooks are written to core.hooksPath if it is set, and otherwise to the repository's hooks directory.`,
+	}
+
+	hooksCmd.AddCommand(newHooksInstallCmd(repo))
+	hooksCmd.AddCommand(newHooksUninstallCmd(repo))
+	return hooksCmd
+}
+
+func newHooksInstallCmd(repo git.Repository) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the pre-push and commit-msg hooks",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := repo.HooksDir()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding hooks directory: %v\n", err)
+				os.Exit(1)
+			}
+
+			installed, err := installHooks(dir, force)
+			for _, path := range installed {
+				fmt.Fprintf(cmd.OutOrStdout(), "Installed hook: %s\n", path)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error installing hooks: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "replace existing hooks that weren't installed by branch")
+
+	return cmd
+}
+
+func newHooksUninstallCmd(repo git.Repository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the hooks installed by branch",
+		Long:  "Remove the pre-push and commit-msg hooks installed by branch. Hooks from anywhere else are left alone.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := repo.HooksDir()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding hooks directory: %v\n", err)
+				os.Exit(1)
+			}
+
+			removed, err := uninstallHooks(dir)
+			for _, path := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "Removed hook: %s\n", path)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error removing hooks: %v\n", err)
+				os.Exit(1)
+			}
+			if len(removed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No hooks installed by branch")
+			}
+		},
+	}
+}
+
+// installHooks writes the hooks into dir, returning the paths written. An
+// existing hook is only replaced if branch installed it, or force is set.
+func installHooks(dir string, force bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	// check every hook first, so a conflict doesn't leave half of them installed
+	if !force {
+		for _, hook := range hooks {
+			path := filepath.Join(dir, hook.name)
+			if ours, err := installedByBranch(path); err != nil {
+				return nil, err
+			} else if !ours {
+				return nil, fmt.Errorf("%s already exists, use --force to replace it", path)
+			}
+		}
+	}
+
+	var installed []string
+	for _, hook := range hooks {
+		path := filepath.Join(dir, hook.name)
+		if err := os.WriteFile(path, []byte(hook.script), 0o755); err != nil {
+			return installed, err
+		}
+		// WriteFile keeps the mode of an existing file
+		if err := os.Chmod(path, 0o755); err != nil {
+			return installed, err
+		}
+		installed = append(installed, path)
+	}
+	return installed, nil
+}
+
+// uninstallHooks removes the hooks branch installed from dir, returning the
+// paths removed.
+func uninstallHooks(dir string) ([]string, error) {
+	var removed []string
+	for _, hook := range hooks {
+		path := filepath.Join(dir, hook.name)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if ours, err := installedByBranch(path); err != nil {
+			return removed, err
+		} else if !ours {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// installedByBranch reports whether the hook at path was written by branch.
+// A missing hook counts, as there is nothing of anyone else's to replace.
+func installedByBranch(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(data), hookMarker), nil
+}
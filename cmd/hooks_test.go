@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestInstallHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]string
+		force    bool
+		wantErr  bool
+		// want is the hooks expected to have been written by branch
+		want []string
+	}{
+		{
+			name: "empty hooks directory",
+			want: []string{"commit-msg", "pre-push"},
+		},
+		{
+			name:     "reinstall over our own hooks",
+			existing: map[string]string{"pre-push": "#!/bin/sh\n" + hookMarker + "\nold version\n"},
+			want:     []string{"commit-msg", "pre-push"},
+		},
+		{
+			name:     "existing hook from elsewhere",
+			existing: map[string]string{"pre-push": "#!/bin/sh\nmake test\n"},
+			wantErr:  true,
+		},
+		{
+			name:     "existing hook replaced with force",
+			existing: map[string]string{"pre-push": "#!/bin/sh\nmake test\n"},
+			force:    true,
+			want:     []string{"commit-msg", "pre-push"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "hooks")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := installHooks(dir, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("installHooks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, hook := range hooks {
+				path := filepath.Join(dir, hook.name)
+				data, _ := os.ReadFile(path)
+				written := string(data) == hook.script
+				wanted := slices.Contains(tt.want, hook.name)
+				if written != wanted {
+					t.Errorf("%s written = %v, want %v", hook.name, written, wanted)
+				}
+				if info, err := os.Stat(path); wanted && (err != nil || info.Mode().Perm()&0o111 == 0) {
+					t.Errorf("%s should be executable", hook.name)
+				}
+			}
+		})
+	}
+}
+
+func TestUninstallHooks(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := installHooks(dir, false); err != nil {
+		t.Fatalf("installHooks() unexpected error: %v", err)
+	}
+	foreign := filepath.Join(dir, "pre-push")
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\nmake test\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := uninstallHooks(dir)
+	if err != nil {
+		t.Fatalf("uninstallHooks() unexpected error: %v", err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "commit-msg" {
+		t.Errorf("uninstallHooks() = %v, want only commit-msg removed", removed)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("hook from elsewhere should be kept: %v", err)
+	}
+}
+
+func TestHooksCmd(t *testing.T) {
+	repo := git.NewFake()
+	repo.Dir = t.TempDir()
+	repo.Settings["core.hooksPath"] = ".githooks"
+
+	rootCmd := NewRootCmd(config.Default(), repo, "test")
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"hooks", "install"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+
+	for _, hook := range hooks {
+		if _, err := os.Stat(filepath.Join(repo.Dir, ".githooks", hook.name)); err != nil {
+			t.Errorf("%s should be installed in core.hooksPath: %v", hook.name, err)
+		}
+	}
+	if !strings.Contains(out.String(), "Installed hook:") {
+		t.Errorf("output should list the installed hooks:\n%s", out.String())
+	}
+}
+
+func TestCommitMsgHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available, skipping test")
+	}
+
+	tests := []struct {
+		name   string
+		ticket string
+		// branch, if set, is checked out for the real branch command to
+		// parse, instead of stubbing it to print ticket
+		branch  string
+		message string
+		want    string
+	}{
+		{
+			name:    "prefixes the ticket",
+			ticket:  "PIP-1234",
+			message: "add login form\n",
+			want:    "PIP-1234: add login form\n",
+		},
+		{
+			name:    "ticket already present",
+			ticket:  "PIP-1234",
+			message: "fix pip-1234 typo\n",
+			want:    "fix pip-1234 typo\n",
+		},
+		{
+			name:    "ticket only in a comment",
+			ticket:  "PIP-1234",
+			message: "add login form\n# On branch feat/pip-1234-add-login\n",
+			want:    "PIP-1234: add login form\n# On branch feat/pip-1234-add-login\n",
+		},
+		{
+			name:    "empty message",
+			ticket:  "PIP-1234",
+			message: "\n# Please enter the commit message\n",
+			want:    "\n# Please enter the commit message\n",
+		},
+		{
+			name:    "branch without a ticket",
+			message: "add login form\n",
+			want:    "add login form\n",
+		},
+		{
+			name:    "ticket parsed from the branch",
+			branch:  "feat/pip-12-add-login",
+			message: "add login form\n",
+			want:    "PIP-12: add login form\n",
+		},
+		{
+			name:    "branch starting with a number",
+			branch:  "chore/2024-cleanup",
+			message: "tidy up\n",
+			want:    "tidy up\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// a stand-in for branch current --ticket
+			stub := "#!/bin/sh\nexit 1\n"
+			switch {
+			case tt.branch != "":
+				self, err := os.Executable()
+				if err != nil {
+					t.Fatal(err)
+				}
+				stub = "#!/bin/sh\nexec '" + self + "' -test.run='^TestBranchHelper$' -- \"$@\"\n"
+			case tt.ticket != "":
+				stub = "#!/bin/sh\necho " + tt.ticket + "\n"
+			}
+			if err := os.WriteFile(filepath.Join(dir, "branch"), []byte(stub), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			hook := filepath.Join(dir, "commit-msg")
+			msg := filepath.Join(dir, "COMMIT_EDITMSG")
+			if err := os.WriteFile(hook, []byte(commitMsgHook), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(msg, []byte(tt.message), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command("sh", hook, msg)
+			cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"), helperBranchEnv+"="+tt.branch)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("commit-msg hook failed: %v\n%s", err, output)
+			}
+
+			got, _ := os.ReadFile(msg)
+			if string(got) != tt.want {
+				t.Errorf("commit message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// helperBranchEnv names the branch checked out when TestBranchHelper runs.
+const helperBranchEnv = "HOOK_TEST_BRANCH"
+
+// TestBranchHelper isn't a real test: hook tests run the test binary with it
+// as the branch command, with the arguments after "--", on the branch named
+// by helperBranchEnv.
+func TestBranchHelper(t *testing.T) {
+	current := os.Getenv(helperBranchEnv)
+	if current == "" {
+		t.Skip("only run as the branch command by hook tests")
+	}
+
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	repo := git.NewFake(current)
+	repo.Current = current
+
+	// numbers are read as GitHub issues, so hooks must tell inferred tickets apart
+	cfg := config.Default()
+	cfg.TicketPatterns[0].Bare = true
+
+	rootCmd := NewRootCmd(cfg, repo, "test")
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/owenrumney/branch/internal/branch"
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// Lint rules, reported with each violation so scripts can tell them apart.
+const (
+	ruleRefFormat  = "ref-format"
+	ruleTemplate   = "template"
+	ruleType       = "type"
+	ruleTicket     = "ticket"
+	ruleLength     = "max-length"
+	ruleNormalised = "normalised"
+)
+
+type violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type lintResult struct {
+	Name string `json:"name"`
+	// Ignored is set for names matching lint_ignore, which are never checked.
+	Ignored    bool        `json:"ignored,omitempty"`
+	Violations []violation `json:"violations"`
+}
+
+func newLintCmd(cfg *config.Config, repo git.Repository) *cobra.Command {
+	var (
+		format        string
+		requireTicket bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint [name...]",
+		Short: "Check branch names against the naming convention",
+		Long: `Check branch names against the branch commands, ticket patterns, branch_template and max_length
+from the config. Without arguments the current branch is checked; use - to read names from stdin, one per line.
+
+Names matching a lint_ignore pattern, such as main, are always accepted.
+The exit status is 1 if any name has a violation, so it can be used in CI and git hooks.
+
+Examples:
+  branch lint
+  git branch --format='%(refname:short)' | branch lint - --format json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "text" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Error: unknown format %q, expected text or json\n", format)
+				os.Exit(1)
+			}
+
+			names, err := lintNames(cmd.InOrStdin(), repo, args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading branch names: %v\n", err)
+				os.Exit(1)
+			}
+
+			// warn once here rather than failing every name on the template
+			if err := branch.Parseable(cfg.BranchTemplate); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping the template, type, ticket and normalised checks: %v\n", err)
+			}
+
+			results := make([]lintResult, 0, len(names))
+			failed := false
+			for _, name := range names {
+				result := lintName(cfg, name, requireTicket || cfg.RequireTicket)
+				failed = failed || len(result.Violations) > 0
+				results = append(results, result)
+			}
+
+			if format == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					fmt.Fprintf(os.Stderr, "Error encoding results: %v\n", err)
+					os.Exit(1)
+				}
+			} else {
+				printLintResults(cmd.OutOrStdout(), results)
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+	cmd.Flags().BoolVar(&requireTicket, "require-ticket", false, "require every name to contain a ticket (default from require_ticket)")
+
+	return cmd
+}
+
+// lintNames returns the names to check: the arguments, the lines of stdin
+// for -, or the current branch.
+func lintNames(stdin io.Reader, repo git.Repository, args []string) ([]string, error) {
+	if len(args) == 0 {
+		name, err := repo.CurrentBranch()
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+
+	var names []string
+	for _, arg := range args {
+		if arg != "-" {
+			names = append(names, arg)
+			continue
+		}
+
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				names = append(names, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// lintName checks a single branch name against the convention. Only the
+// ref format and length are checked if the template can't be parsed back.
+func lintName(cfg *config.Config, name string, requireTicket bool) lintResult {
+	result := lintResult{Name: name, Violations: []violation{}}
+	add := func(rule, format string, args ...any) {
+		result.Violations = append(result.Violations, violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, pattern := range cfg.LintIgnore {
+		if matched, _ := path.Match(pattern, name); matched {
+			result.Ignored = true
+			return result
+		}
+	}
+
+	if err := git.CheckRefName(name); err != nil {
+		add(ruleRefFormat, "not a valid git branch name: %v", err)
+	}
+
+	if cfg.MaxLength > 0 && len(name) > cfg.MaxLength {
+		add(ruleLength, "%d characters long, the maximum is %d", len(name), cfg.MaxLength)
+	}
+
+	if branch.Parseable(cfg.BranchTemplate) != nil {
+		return result
+	}
+
+	parsed, err := parseName(cfg, name)
+	if err != nil {
+		// tell an unknown type apart from a name that doesn't follow the
+		// template at all; a lone word like "experiment" is the latter
+		anyType, err := branch.Parse(name, branch.ParseOptions{Template: cfg.BranchTemplate, IsTicket: cfg.IsTicket, IsBareTicket: cfg.IsBareTicket})
+		if err == nil && anyType.Type != "" && anyType != (branch.Parsed{Type: anyType.Type}) {
+			add(ruleType, "unknown branch type %q, expected one of %s", anyType.Type, strings.Join(branchTypes(cfg), ", "))
+		} else {
+			add(ruleTemplate, "does not follow the branch template %q", templateOrDefault(cfg.BranchTemplate))
+		}
+		return result
+	}
+
+	if requireTicket && parsed.Ticket == "" {
+		add(ruleTicket, "no ticket matching the ticket patterns")
+	}
+
+	if expected, ok := normalise(cfg, parsed); ok && expected != name {
+		add(ruleNormalised, "not normalised, expected %q", expected)
+	}
+
+	return result
+}
+
+// normalise regenerates a parsed name, giving the name branch would have
+// created for the same type, ticket and description.
+func normalise(cfg *config.Config, parsed branch.Parsed) (string, bool) {
+	var description []string
+	if parsed.Slug != "" {
+		description = strings.Split(parsed.Slug, "-")
+	}
+
+	var now time.Time
+	if parsed.Date != "" {
+		date, err := time.Parse("2006-01-02", parsed.Date)
+		if err != nil {
+			return "", false
+		}
+		now = date
+	}
+
+	ticketCase := cfg.TicketCase
+	if ticket, ok := cfg.ParseTicket(parsed.Ticket); ok {
+		ticketCase = ticket.Case
+	}
+
+	result, err := branch.Generate(parsed.Type, parsed.Ticket, description, branch.Options{
+		Template:   cfg.BranchTemplate,
+		User:       parsed.User,
+		Now:        now,
+		TicketCase: ticketCase,
+		Locale:     cfg.Transliterate,
+	})
+	if err != nil {
+		return "", false
+	}
+	return result.Name, true
+}
+
+func templateOrDefault(tmpl string) string {
+	if tmpl == "" {
+		return branch.DefaultTemplate
+	}
+	return tmpl
+}
+
+func printLintResults(w io.Writer, results []lintResult) {
+	for _, result := range results {
+		switch {
+		case result.Ignored:
+			fmt.Fprintf(w, "%s: ignored\n", result.Name)
+		case len(result.Violations) == 0:
+			fmt.Fprintf(w, "%s: ok\n", result.Name)
+		default:
+			for _, v := range result.Violations {
+				fmt.Fprintf(w, "%s: %s [%s]\n", result.Name, v.Message, v.Rule)
+			}
+		}
+	}
+}
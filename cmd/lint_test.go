@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestLintName(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           func(cfg *config.Config)
+		branch        string
+		requireTicket bool
+		wantRules     []string
+		wantIgnored   bool
+	}{
+		{
+			name:   "conforming name",
+			branch: "feat/pip-1234-add-login",
+		},
+		{
+			name:   "conforming name without a ticket",
+			branch: "fix/crash-on-start",
+		},
+		{
+			name:        "ignored long-lived branch",
+			branch:      "main",
+			wantIgnored: true,
+		},
+		{
+			name:        "ignored by glob",
+			cfg:         func(cfg *config.Config) { cfg.LintIgnore = []string{"release/*"} },
+			branch:      "release/1.2",
+			wantIgnored: true,
+		},
+		{
+			name:      "not following the template",
+			branch:    "experiment",
+			wantRules: []string{ruleTemplate},
+		},
+		{
+			name:      "unknown type",
+			branch:    "wip/spike",
+			wantRules: []string{ruleType},
+		},
+		{
+			name:          "missing ticket",
+			branch:        "fix/crash-on-start",
+			requireTicket: true,
+			wantRules:     []string{ruleTicket},
+		},
+		{
+			name:      "missing ticket required by config",
+			cfg:       func(cfg *config.Config) { cfg.RequireTicket = true },
+			branch:    "fix/crash-on-start",
+			wantRules: []string{ruleTicket},
+		},
+		{
+			name:      "too long",
+			cfg:       func(cfg *config.Config) { cfg.MaxLength = 10 },
+			branch:    "fix/crash-on-start",
+			wantRules: []string{ruleLength},
+		},
+		{
+			name:      "not normalised",
+			branch:    "feat/PIP-1234-Add_Login",
+			wantRules: []string{ruleNormalised},
+		},
+		{
+			name:   "truncated by the template",
+			cfg:    func(cfg *config.Config) { cfg.BranchTemplate = "{{.Type}}/{{truncate 10 .Slug}}" },
+			branch: "feat/add-the-lo",
+		},
+		{
+			name: "template that can't be parsed",
+			cfg: func(cfg *config.Config) {
+				cfg.BranchTemplate = "{{.Type}}/{{if .Ticket}}{{.Ticket}}-{{end}}{{.Slug}}"
+				cfg.RequireTicket = true
+			},
+			branch: "feat/pip-1234-add-login",
+		},
+		{
+			name: "template that can't be parsed still checks the length",
+			cfg: func(cfg *config.Config) {
+				cfg.BranchTemplate = "{{.Type}}/{{if .Ticket}}{{.Ticket}}-{{end}}{{.Slug}}"
+				cfg.MaxLength = 10
+			},
+			branch:    "feat/pip-1234-add-login",
+			wantRules: []string{ruleLength},
+		},
+		{
+			name:      "invalid ref",
+			branch:    "fix/crash..start",
+			wantRules: []string{ruleRefFormat, ruleNormalised},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+
+			got := lintName(cfg, tt.branch, tt.requireTicket || cfg.RequireTicket)
+			if got.Ignored != tt.wantIgnored {
+				t.Errorf("lintName(%q).Ignored = %v, want %v", tt.branch, got.Ignored, tt.wantIgnored)
+			}
+
+			var rules []string
+			for _, v := range got.Violations {
+				rules = append(rules, v.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("lintName(%q) rules = %v, want %v (%+v)", tt.branch, rules, tt.wantRules, got.Violations)
+			}
+		})
+	}
+}
+
+func TestLintNames(t *testing.T) {
+	repo := git.NewFake()
+	repo.Current = "feat/pip-1-add-login"
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  []string
+	}{
+		{
+			name: "current branch by default",
+			want: []string{"feat/pip-1-add-login"},
+		},
+		{
+			name: "arguments",
+			args: []string{"fix/a", "fix/b"},
+			want: []string{"fix/a", "fix/b"},
+		},
+		{
+			name:  "stdin",
+			args:  []string{"-"},
+			stdin: "  fix/a\n\nfix/b\n",
+			want:  []string{"fix/a", "fix/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lintNames(strings.NewReader(tt.stdin), repo, tt.args)
+			if err != nil {
+				t.Fatalf("lintNames() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/owenrumney/branch/internal/branch"
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// listedBranch is a branch parsed back into the fields of the convention.
+type listedBranch struct {
+	git.Branch
+	branch.Parsed
+	Updated time.Time
+	// Ahead and Behind are only meaningful if Compared is set.
+	Ahead, Behind int
+	Compared      bool
+}
+
+func newListCmd(cfg *config.Config, repo git.Repository) *cobra.Command {
+	var (
+		remote     bool
+		all        bool
+		base       string
+		typeFilter string
+		ticketGlob string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List branches that follow the naming convention",
+		Long: `List branches that follow the naming convention, split into their type, ticket and description
+using the branch commands, ticket patterns and branch_template from the config.
+
+Each branch shows the date of its last commit and how far it is ahead of and behind its base: --base,
+the base of its branch command, or the default_base config setting.
+
+Examples:
+  branch list --type fix
+  branch list --remote --ticket 'PIP-*'`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			branches, err := repo.ListBranches(remote)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing branches: %v\n", err)
+				os.Exit(1)
+			}
+
+			var listed []listedBranch
+			for _, b := range branches {
+				parsed, err := parseName(cfg, b.Name)
+				if err != nil && !all {
+					continue
+				}
+				l := listedBranch{Parsed: parsed}
+				if typeFilter != "" && l.Type != typeFilter {
+					continue
+				}
+				if ticketGlob != "" {
+					// tickets are matched case-insensitively, as branch names are usually lower case
+					if matched, _ := path.Match(strings.ToUpper(ticketGlob), strings.ToUpper(l.Ticket)); !matched || l.Ticket == "" {
+						continue
+					}
+				}
+				l.Branch = b
+
+				if updated, err := repo.LastCommit(b.FullName()); err == nil {
+					l.Updated = updated
+				}
+				if compareTo := listBase(cfg, base, l.Type); compareTo != "" {
+					if ahead, behind, err := repo.AheadBehind(b.FullName(), compareTo); err == nil {
+						l.Ahead, l.Behind, l.Compared = ahead, behind, true
+					}
+				}
+				listed = append(listed, l)
+			}
+
+			printBranches(cmd, listed)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&remote, "remote", "r", false, "include remote-tracking branches")
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "include branches that don't follow the convention")
+	cmd.Flags().StringVar(&base, "base", "", "ref to count commits ahead and behind against")
+	cmd.Flags().StringVar(&typeFilter, "type", "", "only list branches of this type")
+	cmd.Flags().StringVar(&ticketGlob, "ticket", "", "only list branches whose ticket matches this glob, e.g. 'PIP-*'")
+
+	return cmd
+}
+
+// listBase returns the ref a branch of the given type is compared against.
+func listBase(cfg *config.Config, base, branchType string) string {
+	if base != "" {
+		return base
+	}
+	for _, command := range cfg.BranchCommands {
+		if command.BranchType() == branchType && command.Base != "" {
+			return command.Base
+		}
+	}
+	return cfg.DefaultBase
+}
+
+func printBranches(cmd *cobra.Command, branches []listedBranch) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BRANCH\tTYPE\tTICKET\tDESCRIPTION\tUPDATED\tAHEAD\tBEHIND")
+	for _, b := range branches {
+		updated, ahead, behind := "-", "-", "-"
+		if !b.Updated.IsZero() {
+			updated = b.Updated.Format("2006-01-02")
+		}
+		if b.Compared {
+			ahead, behind = fmt.Sprint(b.Ahead), fmt.Sprint(b.Behind)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			b.FullName(), dash(b.Type), dash(b.Ticket), dash(b.Slug), updated, ahead, behind)
+	}
+	_ = w.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestListCmd(t *testing.T) {
+	newRepo := func() *git.Fake {
+		repo := git.NewFake("feat/pip-1-add-login", "fix/pip-2-crash", "fix/typo", "experiment")
+		repo.Remotes["origin"] = []string{"main", "feat/pip-3-remote"}
+		repo.Commits["fix/pip-2-crash"] = time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+		repo.Divergence["fix/pip-2-crash"] = [2]int{2, 5}
+		return repo
+	}
+
+	tests := []struct {
+		name    string
+		cfg     func(cfg *config.Config)
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "conforming local branches",
+			args:    []string{"list"},
+			want:    []string{"feat/pip-1-add-login", "fix/pip-2-crash", "fix/typo", "2024-03-09"},
+			notWant: []string{"experiment", "origin/", "main"},
+		},
+		{
+			name: "all branches",
+			args: []string{"list", "--all"},
+			want: []string{"experiment", "main"},
+		},
+		{
+			name: "remote branches",
+			args: []string{"list", "--remote"},
+			want: []string{"origin/feat/pip-3-remote"},
+		},
+		{
+			name:    "filter by type",
+			args:    []string{"list", "--type", "fix"},
+			want:    []string{"fix/pip-2-crash", "fix/typo"},
+			notWant: []string{"feat/"},
+		},
+		{
+			name:    "filter by ticket glob",
+			args:    []string{"list", "--ticket", "pip-*", "--type", "fix"},
+			want:    []string{"fix/pip-2-crash"},
+			notWant: []string{"fix/typo", "feat/"},
+		},
+		{
+			name: "ahead and behind against the default base",
+			cfg:  func(cfg *config.Config) { cfg.DefaultBase = "origin/main" },
+			args: []string{"list", "--type", "fix", "--ticket", "PIP-2"},
+			want: []string{"PIP-2", "crash", "2024-03-09  2      5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+
+			rootCmd := NewRootCmd(cfg, newRepo(), "test")
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(tt.args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output should contain %q:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output should not contain %q:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func newNameCmd(cfg *config.Config, repo git.Repository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "name <type> [description...]",
+		Short: "Print the branch name that would be created",
+		Long: `Print the branch name a branch command would create, without touching git.
+
+The output is the bare branch name, so it can be used from scripts and editor integrations.
+
+Examples:
+  branch name feat PIP-1234 implement new feature  ->  feat/pip-1234-implement-new-feature`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			command, ok := cfg.FindCommand(args[0])
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: unknown branch type %q\n", args[0])
+				os.Exit(1)
+			}
+
+			result, err := generateName(cfg, repo, command.BranchType(), args[1:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating branch name: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), result.Name)
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+func TestNameCmd(t *testing.T) {
+	oldEnv := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		args []string
+		want string
+	}{
+		{
+			name: "name command with ticket",
+			args: []string{"name", "feat", "PIP-1234", "implement", "feature"},
+			want: "feat/pip-1234-implement-feature",
+		},
+		{
+			name: "name command without ticket",
+			args: []string{"name", "fix", "resolve", "crash"},
+			want: "fix/resolve-crash",
+		},
+		{
+			name: "name command with alias uses prefix",
+			cfg: &config.Config{BranchCommands: []config.BranchCommand{
+				{Name: "feature", Aliases: []string{"ft"}, Prefix: "feat"},
+			}},
+			args: []string{"name", "ft", "add", "login"},
+			want: "feat/add-login",
+		},
+		{
+			name: "branch command with dry-run",
+			args: []string{"chore", "--dry-run", "update", "deps"},
+			want: "chore/update-deps",
+		},
+		{
+			name: "branch command with print",
+			args: []string{"docs", "--print", "#12", "readme"},
+			want: "docs/12-readme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = config.Default()
+			}
+			rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
+
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs(tt.args)
+
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
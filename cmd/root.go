@@ -2,22 +2,54 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
 	"github.com/spf13/cobra"
 )
 
-func NewRootCmd(cfg *config.Config, version string) *cobra.Command {
+func NewRootCmd(cfg *config.Config, repo git.Repository, version string) *cobra.Command {
 
 	rootCmd := &cobra.Command{
-		Use:     "branch",
-		Short:   "Create git branches with consistent naming patterns",
-		Long:    `A CLI tool for creating git branches using a standardized pattern: <type>/<ticket>-<description>`,
+		Use:   "branch",
+		Short: "Create git branches with consistent naming patterns",
+		Long: `A CLI tool for creating git branches using a standardized pattern: <type>/<ticket>-<description>
+
+The pattern can be changed with the branch_template config setting.`,
 		Version: version,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// the config commands report problems themselves
+			if problems := cfg.Problems(); len(problems) > 0 && !strings.HasPrefix(cmd.CommandPath(), "branch config") {
+				fmt.Fprintf(os.Stderr, "Warning: config has %d problem(s), run 'branch config validate' for details\n", len(problems))
+			}
+		},
+	}
+
+	rootCmd.AddCommand(newNameCmd(cfg, repo))
+	rootCmd.AddCommand(newListCmd(cfg, repo))
+	rootCmd.AddCommand(newCurrentCmd(cfg, repo))
+	rootCmd.AddCommand(newLintCmd(cfg, repo))
+	rootCmd.AddCommand(newHooksCmd(repo))
+	rootCmd.AddCommand(newConfigCmd(repo))
+
+	// branch commands and aliases that collide with a built-in are reported
+	// by config validate, so skip them rather than shadowing the built-in
+	builtins := map[string]bool{"help": true, "completion": true}
+	for _, c := range rootCmd.Commands() {
+		builtins[c.Name()] = true
 	}
 
 	for _, branchCommand := range cfg.BranchCommands {
-		rootCmd.AddCommand(newBranchCmd(branchCommand, fmt.Sprintf("Create a %s branch", branchCommand)))
+		if builtins[branchCommand.Name] {
+			continue
+		}
+		branchCommand.Aliases = slices.DeleteFunc(slices.Clone(branchCommand.Aliases), func(alias string) bool {
+			return builtins[alias]
+		})
+		rootCmd.AddCommand(newBranchCmd(cfg, repo, branchCommand))
 	}
 
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
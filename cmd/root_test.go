@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +22,7 @@ func TestNewRootCmd(t *testing.T) {
 
 	t.Run("creates commands from default config", func(t *testing.T) {
 		cfg := config.Default()
-		rootCmd := NewRootCmd(cfg, "test")
+		rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
 
 		if rootCmd == nil {
 			t.Fatal("NewRootCmd() should not return nil")
@@ -37,10 +39,10 @@ func TestNewRootCmd(t *testing.T) {
 
 	t.Run("creates commands from custom config", func(t *testing.T) {
 		cfg := &config.Config{
-			BranchCommands: []string{"custom1", "custom2", "custom3"},
+			BranchCommands: []config.BranchCommand{{Name: "custom1"}, {Name: "custom2"}, {Name: "custom3"}},
 		}
 
-		rootCmd := NewRootCmd(cfg, "test")
+		rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
 
 		if rootCmd == nil {
 			t.Fatal("NewRootCmd() should not return nil")
@@ -57,7 +59,7 @@ func TestNewRootCmd(t *testing.T) {
 
 	t.Run("hides completion command", func(t *testing.T) {
 		cfg := config.Default()
-		rootCmd := NewRootCmd(cfg, "test")
+		rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
 
 		if !rootCmd.CompletionOptions.HiddenDefaultCmd {
 			t.Error("Completion command should be hidden")
@@ -66,7 +68,7 @@ func TestNewRootCmd(t *testing.T) {
 
 	t.Run("root command has correct metadata", func(t *testing.T) {
 		cfg := config.Default()
-		rootCmd := NewRootCmd(cfg, "test")
+		rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
 
 		if rootCmd.Use != "branch" {
 			t.Errorf("Expected Use to be 'branch', got %q", rootCmd.Use)
@@ -84,7 +86,7 @@ func TestNewRootCmd(t *testing.T) {
 
 func TestNewRootCmdCommandStructure(t *testing.T) {
 	cfg := config.Default()
-	rootCmd := NewRootCmd(cfg, "test")
+	rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
 
 	// Helper to find command by name
 	findCommand := func(name string) *cobra.Command {
@@ -97,7 +99,7 @@ func TestNewRootCmdCommandStructure(t *testing.T) {
 	}
 
 	// Test that each command has the correct structure
-	for _, branchCmd := range cfg.BranchCommands {
+	for _, branchCmd := range cfg.CommandNames() {
 		foundCmd := findCommand(branchCmd)
 
 		if foundCmd == nil {
@@ -120,3 +122,50 @@ func TestNewRootCmdCommandStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestNewRootCmdSkipsBuiltinCollisions(t *testing.T) {
+	cfg := &config.Config{
+		BranchCommands: []config.BranchCommand{{Name: "feat", Aliases: []string{"list", "f"}}, {Name: "config"}, {Name: "help"}},
+	}
+	rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
+
+	if found, _, err := rootCmd.Find([]string{"feat"}); err != nil || !slices.Equal(found.Aliases, []string{"f"}) {
+		t.Errorf("feat should keep only the aliases that don't collide with a built-in, got %v", found.Aliases)
+	}
+	if cfg.BranchCommands[0].Aliases[0] != "list" {
+		t.Error("The config's aliases should be left alone")
+	}
+
+	count := 0
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "config" {
+			count++
+			if c.Use != "config" {
+				t.Error("Built-in config command should not be replaced by a branch command")
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly one config command, got %d", count)
+	}
+}
+
+func TestNewRootCmdCommandMetadata(t *testing.T) {
+	cfg := &config.Config{
+		BranchCommands: []config.BranchCommand{
+			{Name: "feature", Description: "Start a new feature", Aliases: []string{"feat"}, Prefix: "feat"},
+		},
+	}
+	rootCmd := NewRootCmd(cfg, git.NewFake(), "test")
+
+	found, _, err := rootCmd.Find([]string{"feat"})
+	if err != nil {
+		t.Fatalf("Find(feat) unexpected error: %v", err)
+	}
+	if found.Name() != "feature" {
+		t.Errorf("Alias feat should resolve to feature, got %q", found.Name())
+	}
+	if found.Short != "Start a new feature" {
+		t.Errorf("Short = %q, want %q", found.Short, "Start a new feature")
+	}
+}
@@ -1,40 +1,217 @@
 package branch
 
 import (
+	"bytes"
+	"fmt"
 	"regexp"
 	"strings"
+	"text/template"
+	"time"
 )
 
-func Generate(branchType, ticket string, description []string) string {
-	var parts []string
+// DefaultTemplate produces the classic <type>/<ticket>-<description> layout.
+const DefaultTemplate = "{{.Type}}/{{.Ticket}}-{{.Slug}}"
 
-	if ticket != "" {
-		parts = append(parts, ticket)
+// Options controls how a branch name is rendered.
+type Options struct {
+	// Template is a text/template used to build the name. Empty means DefaultTemplate.
+	Template string
+	// User is the git user the branch is created for, exposed as .User.
+	User string
+	// Now is the time used for .Date, defaulting to the current time.
+	Now time.Time
+	// MaxLength caps the length of the generated name. Zero means no limit.
+	MaxLength int
+	// TicketCase is how the ticket is cased: "lower" (the default), "upper"
+	// or "preserve" to keep it as typed.
+	TicketCase string
+	// Locale selects the transliteration rules for non-ASCII characters,
+	// e.g. "de" spells ä as ae. Empty uses the default table.
+	Locale string
+	// Suffix is appended to the rendered name, e.g. to make it unique. It
+	// counts towards MaxLength, but is never shortened itself.
+	Suffix string
+}
+
+// Result is a generated branch name.
+type Result struct {
+	Name string
+	// Truncated reports whether the description was shortened to fit MaxLength.
+	Truncated bool
+	// Fields holds the values the name was rendered from, after any truncation.
+	Fields Fields
+}
+
+// Fields is the data made available to branch name templates.
+type Fields struct {
+	Type   string
+	Ticket string
+	Slug   string
+	User   string
+	Date   string
+}
+
+var templateFuncs = template.FuncMap{
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"truncate": truncate,
+}
+
+func Generate(branchType, ticket string, description []string, opts Options) (Result, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	t, err := parseTemplate(opts.Template)
+	if err != nil {
+		return Result{}, err
 	}
 
-	parts = append(parts, description...)
+	formattedTicket, err := formatTicket(ticket, opts.TicketCase, opts.Locale)
+	if err != nil {
+		return Result{}, err
+	}
 
-	slug := slugify(strings.Join(parts, " "))
+	fields := Fields{
+		Type:   branchType,
+		Ticket: formattedTicket,
+		Slug:   slugify(strings.Join(description, " "), opts.Locale),
+		User:   slugify(opts.User, opts.Locale),
+		Date:   now.Format("2006-01-02"),
+	}
 
-	if slug == "" {
-		return branchType
+	name, err := render(t, fields)
+	if err != nil {
+		return Result{}, err
 	}
+	name += opts.Suffix
 
-	return branchType + "/" + slug
+	if opts.MaxLength <= 0 || len(name) <= opts.MaxLength {
+		return Result{Name: name, Fields: fields}, nil
+	}
+
+	return shorten(t, fields, opts.MaxLength, opts.Suffix)
+}
+
+// shorten drops words from the end of the slug until the name fits in
+// maxLength. Words are never cut: if not even the first one fits, the slug is
+// dropped, as long as a ticket is left to identify the branch. The type,
+// ticket and suffix are never touched, so an error is returned if they alone
+// are too long.
+func shorten(t *template.Template, fields Fields, maxLength int, suffix string) (Result, error) {
+	words := strings.Split(fields.Slug, "-")
+
+	for n := len(words) - 1; n > 0; n-- {
+		fields.Slug = strings.Join(words[:n], "-")
+		name, err := render(t, fields)
+		if err != nil {
+			return Result{}, err
+		}
+		name += suffix
+		if len(name) <= maxLength {
+			return Result{Name: name, Truncated: true, Fields: fields}, nil
+		}
+	}
+
+	if fields.Ticket == "" {
+		return Result{}, fmt.Errorf("description %q does not fit in the maximum length of %d without cutting a word", words[0], maxLength)
+	}
+
+	fields.Slug = ""
+	name, err := render(t, fields)
+	if err != nil {
+		return Result{}, err
+	}
+	name += suffix
+	if len(name) <= maxLength {
+		return Result{Name: name, Truncated: true, Fields: fields}, nil
+	}
+
+	return Result{}, fmt.Errorf("branch name %q is longer than the maximum length of %d without a description", name, maxLength)
 }
 
-func slugify(s string) string {
-	s = strings.ToLower(s)
+func parseTemplate(tmpl string) (*template.Template, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+
+	t, err := template.New("branch").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid branch template: %w", err)
+	}
+	return t, nil
+}
+
+func render(t *template.Template, fields Fields) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("invalid branch template: %w", err)
+	}
+
+	return clean(buf.String()), nil
+}
+
+var separatorRun = regexp.MustCompile(`([-_.])[-_.]+`)
+
+// clean tidies up a rendered name so that empty template fields don't leave
+// dangling separators or empty path segments behind.
+func clean(name string) string {
+	name = separatorRun.ReplaceAllString(name, "$1")
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		segment = strings.Trim(segment, "-_. ")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func truncate(n int, s string) string {
+	if n < 0 || len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// formatTicket makes a ticket safe for a branch name independently of the
+// description, so its case can be kept for tools that link tickets by key.
+func formatTicket(ticket, ticketCase, locale string) (string, error) {
+	switch ticketCase {
+	case "", "lower":
+		return slugify(ticket, locale), nil
+	case "upper":
+		return strings.ToUpper(slugify(ticket, locale)), nil
+	case "preserve":
+		return sanitize(ticket, locale), nil
+	default:
+		return "", fmt.Errorf("unknown ticket case %q, expected lower, upper or preserve", ticketCase)
+	}
+}
+
+func slugify(s, locale string) string {
+	return sanitize(strings.ToLower(s), locale)
+}
+
+var (
+	invalidChars = regexp.MustCompile(`[^a-zA-Z0-9\-]`)
+	hyphenRun    = regexp.MustCompile(`-+`)
+)
+
+// sanitize reduces s to letters, digits and single hyphens, keeping its case.
+func sanitize(s, locale string) string {
 	s = strings.TrimSpace(s)
+	s = transliterate(s, locale)
 
 	s = strings.ReplaceAll(s, " ", "-")
 	s = strings.ReplaceAll(s, "_", "-")
 
-	reg := regexp.MustCompile(`[^a-z0-9\-]`)
-	s = reg.ReplaceAllString(s, "")
+	s = invalidChars.ReplaceAllString(s, "")
 
-	reg = regexp.MustCompile(`-+`)
-	s = reg.ReplaceAllString(s, "-")
+	s = hyphenRun.ReplaceAllString(s, "-")
 
 	s = strings.Trim(s, "-")
 
@@ -1,6 +1,9 @@
 package branch
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestGenerate(t *testing.T) {
 	tests := []struct {
@@ -70,14 +73,206 @@ func TestGenerate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := Generate(tt.branchType, tt.ticket, tt.description)
-			if got != tt.want {
-				t.Errorf("Generate(%q, %q, %v) = %q, want %q", tt.branchType, tt.ticket, tt.description, got, tt.want)
+			got, err := Generate(tt.branchType, tt.ticket, tt.description, Options{})
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Generate(%q, %q, %v) = %q, want %q", tt.branchType, tt.ticket, tt.description, got.Name, tt.want)
 			}
 		})
 	}
 }
 
+func TestGenerateTemplate(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		template    string
+		ticket      string
+		description []string
+		want        string
+	}{
+		{
+			name:        "user scoped with upper ticket",
+			template:    "users/{{.User}}/{{.Type}}/{{upper .Ticket}}/{{.Slug}}",
+			ticket:      "PIP-1234",
+			description: []string{"add", "login"},
+			want:        "users/jane-doe/feat/PIP-1234/add-login",
+		},
+		{
+			name:        "user scoped without ticket drops empty segment",
+			template:    "users/{{.User}}/{{.Type}}/{{upper .Ticket}}/{{.Slug}}",
+			description: []string{"add", "login"},
+			want:        "users/jane-doe/feat/add-login",
+		},
+		{
+			name:        "ticket underscore slug",
+			template:    "{{upper .Ticket}}_{{.Slug}}",
+			ticket:      "PIP-1234",
+			description: []string{"add", "login"},
+			want:        "PIP-1234_add-login",
+		},
+		{
+			name:        "ticket underscore slug without ticket",
+			template:    "{{upper .Ticket}}_{{.Slug}}",
+			description: []string{"add", "login"},
+			want:        "add-login",
+		},
+		{
+			name:        "date and truncate",
+			template:    "{{.Type}}/{{.Date}}-{{.Slug | truncate 5}}",
+			description: []string{"add", "login"},
+			want:        "feat/2024-03-09-add-l",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Template: tt.template, User: "Jane Doe", Now: now}
+			got, err := Generate("feat", tt.ticket, tt.description, opts)
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Generate() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid template returns error", func(t *testing.T) {
+		if _, err := Generate("feat", "", []string{"x"}, Options{Template: "{{.Type"}); err == nil {
+			t.Error("Generate() with invalid template should return error")
+		}
+	})
+
+	t.Run("unknown field returns error", func(t *testing.T) {
+		if _, err := Generate("feat", "", []string{"x"}, Options{Template: "{{.Missing}}"}); err == nil {
+			t.Error("Generate() with unknown field should return error")
+		}
+	})
+}
+
+func TestGenerateMaxLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		ticket        string
+		description   []string
+		maxLength     int
+		suffix        string
+		want          string
+		wantTruncated bool
+	}{
+		{
+			name:        "fits without truncation",
+			ticket:      "PIP-1",
+			description: []string{"add", "login"},
+			maxLength:   20,
+			want:        "feat/pip-1-add-login",
+		},
+		{
+			name:          "truncates at word boundary",
+			ticket:        "PIP-1",
+			description:   []string{"add", "login", "page", "for", "admins"},
+			maxLength:     25,
+			want:          "feat/pip-1-add-login-page",
+			wantTruncated: true,
+		},
+		{
+			name:          "drops a single long word to keep ticket",
+			ticket:        "PIP-1",
+			description:   []string{"internationalisation"},
+			maxLength:     12,
+			want:          "feat/pip-1",
+			wantTruncated: true,
+		},
+		{
+			name:          "drops description to keep ticket",
+			ticket:        "PIP-1234",
+			description:   []string{"add", "login"},
+			maxLength:     13,
+			want:          "feat/pip-1234",
+			wantTruncated: true,
+		},
+		{
+			name:          "suffix counts towards the limit",
+			ticket:        "PIP-1",
+			description:   []string{"add", "login", "page"},
+			maxLength:     22,
+			suffix:        "-2",
+			want:          "feat/pip-1-add-login-2",
+			wantTruncated: true,
+		},
+		{
+			name:        "zero means unlimited",
+			description: []string{"a", "very", "long", "description", "that", "goes", "on"},
+			want:        "feat/a-very-long-description-that-goes-on",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate("feat", tt.ticket, tt.description, Options{MaxLength: tt.maxLength, Suffix: tt.suffix})
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Generate() = %q, want %q", got.Name, tt.want)
+			}
+			if got.Truncated != tt.wantTruncated {
+				t.Errorf("Generate() truncated = %v, want %v", got.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+
+	t.Run("error when the first word does not fit without a ticket", func(t *testing.T) {
+		if _, err := Generate("feat", "", []string{"internationalisation"}, Options{MaxLength: 12}); err == nil {
+			t.Error("Generate() should return error rather than cut a word")
+		}
+	})
+
+	t.Run("error when type and ticket do not fit", func(t *testing.T) {
+		if _, err := Generate("feat", "PIP-1234", []string{"add"}, Options{MaxLength: 8}); err == nil {
+			t.Error("Generate() should return error when type and ticket exceed max length")
+		}
+	})
+}
+
+func TestGenerateTicketCase(t *testing.T) {
+	tests := []struct {
+		name       string
+		ticket     string
+		ticketCase string
+		want       string
+	}{
+		{"default lowercases", "PIP-1234", "", "feat/pip-1234-add-login"},
+		{"lower", "PIP-1234", "lower", "feat/pip-1234-add-login"},
+		{"upper", "pip-1234", "upper", "feat/PIP-1234-add-login"},
+		{"preserve", "PIP-1234", "preserve", "feat/PIP-1234-add-login"},
+		{"preserve normalises separators", "PIP_1234", "preserve", "feat/PIP-1234-add-login"},
+		{"preserve strips hash", "#123", "preserve", "feat/123-add-login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate("feat", tt.ticket, []string{"Add", "Login"}, Options{TicketCase: tt.ticketCase})
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Generate() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown case returns error", func(t *testing.T) {
+		if _, err := Generate("feat", "PIP-1", nil, Options{TicketCase: "title"}); err == nil {
+			t.Error("Generate() with unknown ticket case should return error")
+		}
+	})
+}
+
 func TestSlugify(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -158,7 +353,7 @@ func TestSlugify(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := slugify(tt.input)
+			got := slugify(tt.input, "")
 			if got != tt.want {
 				t.Errorf("slugify(%q) = %q, want %q", tt.input, got, tt.want)
 			}
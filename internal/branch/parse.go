@@ -0,0 +1,294 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ParseOptions controls how a branch name is split back into its fields.
+type ParseOptions struct {
+	// Template is the template the name was generated with. Empty means DefaultTemplate.
+	Template string
+	// Types are the branch types to recognise. Empty accepts any type.
+	Types []string
+	// IsTicket reports whether a word is a ticket reference. Nil means the
+	// name is parsed without a ticket.
+	IsTicket func(string) bool
+	// IsBareTicket reports whether a ticket written with a leading #, e.g.
+	// #456, may appear in names without it, as generated names drop the #.
+	// Nil means a number is never read as a ticket, so chore/2024-cleanup
+	// keeps 2024 in its slug.
+	IsBareTicket func(string) bool
+}
+
+// Parsed is a branch name split back into the fields it was generated from.
+type Parsed struct {
+	Type string
+	// Ticket is the ticket as IsTicket recognised it, e.g. PIP-1234 for a
+	// name containing pip-1234, or empty if the name has no ticket.
+	Ticket string
+	// TicketInferred is set when the ticket was read from a bare number
+	// through IsBareTicket, rather than found in the name as written.
+	TicketInferred bool
+	Slug           string
+	User           string
+	Date           string
+}
+
+// fieldPatterns match the text each field renders to. Type is matched lazily
+// so that a type containing a slash can still be found.
+var fieldPatterns = map[string]string{
+	"Type": `.+?`,
+	"Slug": `[^/]+`,
+	"User": `[^/]+`,
+	"Date": `\d{4}-\d{2}-\d{2}`,
+}
+
+// placeholder stands in for a field while the template is rendered, so that
+// the rendered text can be turned into a regular expression. It survives
+// clean and the lower and upper template functions.
+func placeholder(field string) string {
+	return "zzbranch" + strings.ToLower(field) + "zz"
+}
+
+// placeholders match a placeholder, along with the length it was truncated
+// to, if any.
+var placeholders = regexp.MustCompile(`(?i)zzbranch(type|ticket|slug|user|date)(\d*)zz`)
+
+// parseFuncs replace the template functions that change the length of a
+// field, so the placeholder records the limit instead of being cut short.
+var parseFuncs = template.FuncMap{
+	"truncate": func(n int, s string) (string, error) {
+		if !placeholders.MatchString(s) {
+			return truncate(n, s), nil
+		}
+		m := placeholders.FindStringSubmatch(s)
+		if m[0] != s || m[2] != "" {
+			return "", fmt.Errorf("cannot parse branch names: truncate must be applied to a single field")
+		}
+		switch {
+		case n < 0:
+			return s, nil
+		case n == 0:
+			return "", nil
+		}
+		return fmt.Sprintf("zzbranch%s%dzz", strings.ToLower(m[1]), n), nil
+	},
+}
+
+// wordSeparators split a name into the words a ticket can be made of.
+var wordSeparators = regexp.MustCompile(`[/_.-]`)
+
+// maxTicketWords is the most words a single ticket is looked for across.
+const maxTicketWords = 3
+
+// Parse is the inverse of Generate: it splits name back into its type,
+// ticket and slug according to the template. As tickets are recognised with
+// IsTicket, a ticket-like word that isn't one stays part of the slug. It
+// returns an error if the name doesn't follow the template.
+func Parse(name string, opts ParseOptions) (Parsed, error) {
+	t, used, err := parseableTemplate(opts.Template)
+	if err != nil {
+		return Parsed{}, err
+	}
+	t = t.Funcs(parseFuncs)
+
+	var types []string
+	if used["Type"] {
+		types = slices.Clone(opts.Types)
+		// prefer the longest type, so feat/ui is not mistaken for feat
+		slices.SortStableFunc(types, func(a, b string) int { return len(b) - len(a) })
+	}
+	if len(types) == 0 {
+		types = []string{""}
+	}
+
+	var tickets []ticketCandidate
+	if used["Ticket"] {
+		tickets = ticketCandidates(name, opts)
+	}
+	// a name without a ticket is only tried once no ticket matches
+	tickets = append(tickets, ticketCandidate{})
+
+	for _, branchType := range types {
+		for _, ticket := range tickets {
+			for _, withSlug := range []bool{true, false} {
+				parsed, ok, err := match(t, name, branchType, ticket, withSlug)
+				if err != nil {
+					return Parsed{}, err
+				}
+				if ok {
+					return parsed, nil
+				}
+			}
+		}
+	}
+
+	if len(types) > 1 || types[0] != "" {
+		return Parsed{}, fmt.Errorf("branch name %q does not match the template %q with any of the types %s", name, templateText(opts.Template), strings.Join(opts.Types, ", "))
+	}
+	return Parsed{}, fmt.Errorf("branch name %q does not match the template %q", name, templateText(opts.Template))
+}
+
+// ticketCandidate is a run of words in a name that IsTicket accepts.
+type ticketCandidate struct {
+	// text is the ticket as it appears in the name.
+	text string
+	// ticket is the form IsTicket or IsBareTicket accepted.
+	ticket string
+	// inferred is set when IsBareTicket accepted it.
+	inferred bool
+}
+
+// ticketCandidates finds the runs of words in name that are tickets, longest
+// first at each position. Names are usually lower case, so the upper case
+// form is tried as well, and a leading # only where IsBareTicket allows it.
+func ticketCandidates(name string, opts ParseOptions) []ticketCandidate {
+	if opts.IsTicket == nil {
+		return nil
+	}
+
+	words := wordSeparators.Split(name, -1)
+	var candidates []ticketCandidate
+	for i := range words {
+		for n := min(maxTicketWords, len(words)-i); n > 0; n-- {
+			text := strings.Join(words[i:i+n], "-")
+			if text == "" {
+				continue
+			}
+			switch {
+			case opts.IsTicket(text):
+				candidates = append(candidates, ticketCandidate{text: text, ticket: text})
+			case opts.IsTicket(strings.ToUpper(text)):
+				candidates = append(candidates, ticketCandidate{text: text, ticket: strings.ToUpper(text)})
+			case opts.IsBareTicket != nil && opts.IsBareTicket("#"+text):
+				candidates = append(candidates, ticketCandidate{text: text, ticket: "#" + text, inferred: true})
+			}
+		}
+	}
+	return candidates
+}
+
+// match renders the template with the given type and ticket, and the other
+// fields as placeholders, and checks whether name fits the result.
+func match(t *template.Template, name, branchType string, ticket ticketCandidate, withSlug bool) (Parsed, bool, error) {
+	fields := Fields{
+		Type:   branchType,
+		Ticket: ticket.text,
+		User:   placeholder("User"),
+		Date:   placeholder("Date"),
+	}
+	if branchType == "" {
+		fields.Type = placeholder("Type")
+	}
+	if withSlug {
+		fields.Slug = placeholder("Slug")
+	}
+
+	rendered, err := render(t, fields)
+	if err != nil {
+		return Parsed{}, false, err
+	}
+
+	var groups []string
+	pattern := placeholders.ReplaceAllStringFunc(regexp.QuoteMeta(rendered), func(p string) string {
+		m := placeholders.FindStringSubmatch(p)
+		field := strings.ToUpper(m[1][:1]) + strings.ToLower(m[1][1:])
+		groups = append(groups, field)
+		if limit := m[2]; limit != "" {
+			// a truncated field can be cut anywhere, so only its length is known
+			if field == "Type" {
+				return "(.{1," + limit + "}?)"
+			}
+			return "([^/]{1," + limit + "})"
+		}
+		return "(" + fieldPatterns[field] + ")"
+	})
+
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return Parsed{}, false, err
+	}
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return Parsed{}, false, nil
+	}
+
+	parsed := Parsed{Type: branchType, Ticket: ticket.ticket, TicketInferred: ticket.inferred}
+	for i, field := range groups {
+		switch field {
+		case "Type":
+			parsed.Type = m[i+1]
+		case "Slug":
+			parsed.Slug = m[i+1]
+		case "User":
+			parsed.User = m[i+1]
+		case "Date":
+			parsed.Date = m[i+1]
+		}
+	}
+	return parsed, true, nil
+}
+
+// Parseable reports whether names generated with tmpl can be parsed back by
+// Parse, returning why not otherwise, e.g. because tmpl uses if or range.
+func Parseable(tmpl string) error {
+	_, _, err := parseableTemplate(tmpl)
+	return err
+}
+
+// parseableTemplate parses tmpl, returning the fields it uses, or an error
+// if names generated with it can't be parsed back.
+func parseableTemplate(tmpl string) (*template.Template, map[string]bool, error) {
+	t, err := parseTemplate(tmpl)
+	if err != nil {
+		return nil, nil, err
+	}
+	used := make(map[string]bool)
+	if err := templateFields(t.Tree.Root, used); err != nil {
+		return nil, nil, err
+	}
+	return t, used, nil
+}
+
+// templateFields records the fields the template uses in used. It rejects
+// templates whose output can't be traced back to fields, such as ones with
+// conditionals or loops.
+func templateFields(node parse.Node, used map[string]bool) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			if err := templateFields(child, used); err != nil {
+				return err
+			}
+		}
+	case *parse.TextNode:
+	case *parse.ActionNode:
+		found := false
+		for _, cmd := range n.Pipe.Cmds {
+			for _, arg := range cmd.Args {
+				if field, ok := arg.(*parse.FieldNode); ok {
+					used[field.Ident[0]] = true
+					found = true
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("cannot parse branch names: template action %s does not use a field", n)
+		}
+	default:
+		return fmt.Errorf("cannot parse branch names: template uses %s", n)
+	}
+	return nil
+}
+
+func templateText(tmpl string) string {
+	if tmpl == "" {
+		return DefaultTemplate
+	}
+	return tmpl
+}
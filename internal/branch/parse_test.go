@@ -0,0 +1,205 @@
+package branch
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tickets := regexp.MustCompile(`^(#\d+|[A-Z]+-\d+)$`)
+	isTicket := tickets.MatchString
+	isBareTicket := regexp.MustCompile(`^#\d+$`).MatchString
+	types := []string{"feat", "fix", "docs", "chore", "feat/ui"}
+
+	tests := []struct {
+		name     string
+		template string
+		types    []string
+		// bare reads numbers as GitHub issues
+		bare    bool
+		branch  string
+		want    Parsed
+		wantErr bool
+	}{
+		{
+			name:   "type, ticket and slug",
+			branch: "feat/pip-1234-add-login",
+			want:   Parsed{Type: "feat", Ticket: "PIP-1234", Slug: "add-login"},
+		},
+		{
+			name:   "github issue",
+			bare:   true,
+			branch: "docs/456-update-guide",
+			want:   Parsed{Type: "docs", Ticket: "#456", TicketInferred: true, Slug: "update-guide"},
+		},
+		{
+			name:   "leading number is not a ticket",
+			branch: "chore/2024-cleanup",
+			want:   Parsed{Type: "chore", Slug: "2024-cleanup"},
+		},
+		{
+			name:   "no ticket",
+			branch: "fix/crash-on-start",
+			want:   Parsed{Type: "fix", Slug: "crash-on-start"},
+		},
+		{
+			name:   "ticket only",
+			branch: "fix/pip-7",
+			want:   Parsed{Type: "fix", Ticket: "PIP-7"},
+		},
+		{
+			name:   "ticket-like word later in the slug",
+			branch: "fix/revert-pip-7",
+			want:   Parsed{Type: "fix", Slug: "revert-pip-7"},
+		},
+		{
+			name:   "longest type wins",
+			branch: "feat/ui/pip-1-button",
+			want:   Parsed{Type: "feat/ui", Ticket: "PIP-1", Slug: "button"},
+		},
+		{
+			name:   "any type when none are given",
+			types:  []string{},
+			branch: "wip/pip-1-spike",
+			want:   Parsed{Type: "wip", Ticket: "PIP-1", Slug: "spike"},
+		},
+		{
+			name:     "custom template",
+			template: "users/{{.User}}/{{.Type}}/{{upper .Ticket}}/{{.Slug}}",
+			branch:   "users/jane-doe/feat/PIP-1234/add-login",
+			want:     Parsed{Type: "feat", Ticket: "PIP-1234", Slug: "add-login", User: "jane-doe"},
+		},
+		{
+			name:     "custom template without the optional ticket",
+			template: "users/{{.User}}/{{.Type}}/{{upper .Ticket}}/{{.Slug}}",
+			branch:   "users/jane-doe/feat/add-login",
+			want:     Parsed{Type: "feat", Slug: "add-login", User: "jane-doe"},
+		},
+		{
+			name:     "template without a type",
+			template: "{{.User}}/{{upper .Ticket}}/{{.Slug}}",
+			branch:   "jane/OPS-12/rotate-keys",
+			want:     Parsed{Ticket: "OPS-12", Slug: "rotate-keys", User: "jane"},
+		},
+		{
+			name:     "date",
+			template: "{{.Type}}/{{.Date}}/{{.Slug}}",
+			branch:   "fix/2024-03-09/crash",
+			want:     Parsed{Type: "fix", Slug: "crash", Date: "2024-03-09"},
+		},
+		{
+			name:     "truncated slug",
+			template: "{{.Type}}/{{truncate 10 .Slug}}",
+			branch:   "feat/add-the-lo",
+			want:     Parsed{Type: "feat", Slug: "add-the-lo"},
+		},
+		{
+			name:     "truncated slug shorter than the limit",
+			template: "{{.Type}}/{{upper .Ticket}}-{{truncate 10 .Slug}}",
+			branch:   "fix/PIP-7-crash",
+			want:     Parsed{Type: "fix", Ticket: "PIP-7", Slug: "crash"},
+		},
+		{
+			name:     "slug longer than the truncate limit",
+			template: "{{.Type}}/{{truncate 10 .Slug}}",
+			branch:   "feat/add-the-login",
+			wantErr:  true,
+		},
+		{
+			name:     "truncated twice",
+			template: "{{.Type}}/{{truncate 10 .Slug | truncate 5}}",
+			branch:   "feat/add-t",
+			wantErr:  true,
+		},
+		{
+			name:    "unknown type",
+			branch:  "wip/something",
+			wantErr: true,
+		},
+		{
+			name:    "not following the template",
+			branch:  "main",
+			wantErr: true,
+		},
+		{
+			name:     "template with conditionals",
+			template: "{{.Type}}/{{if .Ticket}}{{.Ticket}}-{{end}}{{.Slug}}",
+			branch:   "feat/login",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := ParseOptions{Template: tt.template, Types: types, IsTicket: isTicket}
+			if tt.types != nil {
+				opts.Types = tt.types
+			}
+			if tt.bare {
+				opts.IsBareTicket = isBareTicket
+			}
+
+			got, err := Parse(tt.branch, opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %+v, want error", tt.branch, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.branch, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	isTicket := regexp.MustCompile(`^[A-Z]+-\d+$`).MatchString
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	for _, tmpl := range []string{
+		DefaultTemplate,
+		"{{.Type}}/{{upper .Ticket}}/{{.Slug}}",
+		"{{.User}}/{{.Type}}-{{.Ticket}}-{{.Slug}}",
+		"{{.Date}}/{{.Type}}/{{.Ticket}}_{{.Slug}}",
+		"{{.Type}}/{{.Ticket}}-{{truncate 40 .Slug}}",
+	} {
+		for _, ticket := range []string{"PIP-12", ""} {
+			result, err := Generate("feat", ticket, []string{"add", "login", "page"}, Options{Template: tmpl, User: "Jane Doe", Now: now})
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+
+			got, err := Parse(result.Name, ParseOptions{Template: tmpl, Types: []string{"feat"}, IsTicket: isTicket})
+			if err != nil {
+				t.Errorf("Parse(%q) unexpected error: %v", result.Name, err)
+				continue
+			}
+			if got.Type != "feat" || got.Ticket != ticket || got.Slug != "add-login-page" {
+				t.Errorf("Parse(%q) with template %q = %+v", result.Name, tmpl, got)
+			}
+		}
+	}
+}
+
+func TestParseable(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{tmpl: ""},
+		{tmpl: "{{.Type}}/{{truncate 10 .Slug}}"},
+		{tmpl: "{{.Type}}/{{if .Ticket}}{{.Ticket}}-{{end}}{{.Slug}}", wantErr: true},
+		{tmpl: "{{range .Type}}x{{end}}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := Parseable(tt.tmpl); (err != nil) != tt.wantErr {
+			t.Errorf("Parseable(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
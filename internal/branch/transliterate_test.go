@@ -0,0 +1,34 @@
+package branch
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		locale string
+		want   string
+	}{
+		{"french accents", "café naïve résumé", "", "cafe-naive-resume"},
+		{"german default", "größe ändern", "", "grosse-andern"},
+		{"german locale", "größe ändern", "de", "groesse-aendern"},
+		{"polish", "zażółć gęślą jaźń", "", "zazolc-gesla-jazn"},
+		{"turkish", "İstanbul ağı", "", "istanbul-agi"},
+		{"danish default", "blåbær sø", "", "blabaer-so"},
+		{"danish locale", "blåbær sø", "da", "blaabaer-soe"},
+		{"russian", "Привет мир", "", "privet-mir"},
+		{"greek", "Καλημέρα", "", "kalimera"},
+		{"decomposed accents", "café", "", "cafe"},
+		{"none drops characters", "café naïve", LocaleNone, "caf-nave"},
+		{"unknown characters dropped", "fix 漢字 bug", "", "fix-bug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slugify(tt.input, tt.locale)
+			if got != tt.want {
+				t.Errorf("slugify(%q, %q) = %q, want %q", tt.input, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package branch
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultWorktreeDir places a worktree next to the repository, named after it
+// and the branch description.
+const DefaultWorktreeDir = "../{{.Repo}}-{{.Slug}}"
+
+// WorktreeFields is the data made available to worktree_dir templates: the
+// branch name fields plus the repository and full branch name.
+type WorktreeFields struct {
+	Fields
+	// Repo is the name of the repository's top-level directory.
+	Repo string
+	// Branch is the full generated branch name.
+	Branch string
+}
+
+// WorktreePath renders a worktree_dir template. Empty means DefaultWorktreeDir.
+// A relative result is resolved against root, the repository's top-level
+// directory, rather than the working directory.
+func WorktreePath(tmpl, root string, fields WorktreeFields) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultWorktreeDir
+	}
+
+	t, err := template.New("worktree").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid worktree template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("invalid worktree template: %w", err)
+	}
+
+	path := cleanPath(buf.String())
+	if path == "" {
+		return "", fmt.Errorf("worktree template %q rendered an empty path", tmpl)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// cleanPath is clean for paths: empty fields don't leave dangling separators
+// behind, but . and .. segments and leading dots are kept.
+func cleanPath(path string) string {
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range segments {
+		if segment != "." && segment != ".." {
+			segments[i] = strings.Trim(separatorRun.ReplaceAllString(segment, "$1"), "-_ ")
+		}
+	}
+	return filepath.FromSlash(strings.Join(segments, "/"))
+}
@@ -0,0 +1,77 @@
+package branch
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktreePath(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+	fields := WorktreeFields{
+		Fields: Fields{Type: "feat", Ticket: "pip-1", Slug: "add-login"},
+		Repo:   "app",
+		Branch: "feat/pip-1-add-login",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		fields   WorktreeFields
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "default next to the repository",
+			want: "/src/app-add-login",
+		},
+		{
+			name:     "relative to the repository root",
+			template: ".worktrees/{{.Branch}}",
+			want:     "/src/app/.worktrees/feat/pip-1-add-login",
+		},
+		{
+			name:     "absolute path",
+			template: "/tmp/{{.Repo}}/{{.Ticket}}",
+			want:     "/tmp/app/pip-1",
+		},
+		{
+			name:     "empty field leaves no dangling separator",
+			template: "../{{.Repo}}-{{.Ticket}}-{{.Slug}}",
+			fields:   WorktreeFields{Fields: Fields{Slug: "add-login"}, Repo: "app"},
+			want:     "/src/app-add-login",
+		},
+		{
+			name:     "invalid template",
+			template: "../{{.Repo",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown field",
+			template: "../{{.Missing}}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.fields
+			if f == (WorktreeFields{}) {
+				f = fields
+			}
+
+			got, err := WorktreePath(tt.template, root, f)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("WorktreePath() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WorktreePath() unexpected error: %v", err)
+			}
+			if want := filepath.FromSlash(tt.want); got != want {
+				t.Errorf("WorktreePath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import "encoding/json"
+
+// BranchCommand is a subcommand that creates a branch of one type. In config
+// files it is either a plain name or an object:
+//
+//	{"name": "feature", "aliases": ["feat"], "prefix": "feat", "base": "origin/develop"}
+type BranchCommand struct {
+	Name string `json:"name"`
+	// Description is the help text for the command.
+	Description string `json:"description,omitempty"`
+	// Aliases are alternative names for the command.
+	Aliases []string `json:"aliases,omitempty"`
+	// Prefix is the branch type used in the name, if it differs from Name.
+	Prefix string `json:"prefix,omitempty"`
+	// Base is the ref branches of this type start from, overriding default_base.
+	Base string `json:"base,omitempty"`
+}
+
+func (b *BranchCommand) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*b = BranchCommand{Name: name}
+		return nil
+	}
+
+	type plain BranchCommand
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*b = BranchCommand(obj)
+	return nil
+}
+
+// MarshalJSON writes a command that is only a name as the bare name, e.g.
+// "feat", the same short form UnmarshalJSON accepts.
+func (b BranchCommand) MarshalJSON() ([]byte, error) {
+	if b.Description == "" && len(b.Aliases) == 0 && b.Prefix == "" && b.Base == "" {
+		return json.Marshal(b.Name)
+	}
+
+	type plain BranchCommand
+	return json.Marshal(plain(b))
+}
+
+// BranchType returns the type used in branch names for this command.
+func (b BranchCommand) BranchType() string {
+	if b.Prefix != "" {
+		return b.Prefix
+	}
+	return b.Name
+}
+
+// FindCommand returns the branch command with the given name or alias.
+func (c *Config) FindCommand(name string) (BranchCommand, bool) {
+	for _, command := range c.BranchCommands {
+		if command.Name == name {
+			return command, true
+		}
+		for _, alias := range command.Aliases {
+			if alias == name {
+				return command, true
+			}
+		}
+	}
+	return BranchCommand{}, false
+}
+
+// CommandNames returns the names of the branch commands.
+func (c *Config) CommandNames() []string {
+	names := make([]string, 0, len(c.BranchCommands))
+	for _, command := range c.BranchCommands {
+		names = append(names, command.Name)
+	}
+	return names
+}
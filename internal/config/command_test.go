@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestBranchCommandJSON(t *testing.T) {
+	data := `{"branch_commands": ["fix", {"name": "feature", "description": "Start a feature", "aliases": ["feat"], "prefix": "feat", "base": "origin/develop"}]}`
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal() should not error, got: %v", err)
+	}
+
+	if len(cfg.BranchCommands) != 2 {
+		t.Fatalf("Expected 2 branch commands, got %d", len(cfg.BranchCommands))
+	}
+	if cfg.BranchCommands[0].Name != "fix" || cfg.BranchCommands[0].BranchType() != "fix" {
+		t.Errorf("Expected plain fix command, got %+v", cfg.BranchCommands[0])
+	}
+
+	feature := cfg.BranchCommands[1]
+	if feature.Name != "feature" || feature.Description != "Start a feature" || feature.Base != "origin/develop" {
+		t.Errorf("Unexpected feature command %+v", feature)
+	}
+	if !slices.Equal(feature.Aliases, []string{"feat"}) {
+		t.Errorf("Expected aliases [feat], got %v", feature.Aliases)
+	}
+	if feature.BranchType() != "feat" {
+		t.Errorf("BranchType() = %q, want %q", feature.BranchType(), "feat")
+	}
+
+	out, err := json.Marshal(cfg.BranchCommands)
+	if err != nil {
+		t.Fatalf("Marshal() should not error, got: %v", err)
+	}
+	want := `["fix",{"name":"feature","description":"Start a feature","aliases":["feat"],"prefix":"feat","base":"origin/develop"}]`
+	if string(out) != want {
+		t.Errorf("Marshal() = %s, want %s", out, want)
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	cfg := &Config{
+		BranchCommands: []BranchCommand{
+			{Name: "fix"},
+			{Name: "feature", Aliases: []string{"feat", "f"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		wantName string
+		wantOK   bool
+	}{
+		{"fix", "fix", true},
+		{"feature", "feature", true},
+		{"feat", "feature", true},
+		{"f", "feature", true},
+		{"docs", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := cfg.FindCommand(tt.name)
+		if ok != tt.wantOK || got.Name != tt.wantName {
+			t.Errorf("FindCommand(%q) = %q, %v, want %q, %v", tt.name, got.Name, ok, tt.wantName, tt.wantOK)
+		}
+	}
+}
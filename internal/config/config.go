@@ -2,47 +2,164 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strings"
 )
 
 type Config struct {
-	TicketPatterns []string `json:"ticket_patterns"`
-	BranchCommands []string `json:"branch_commands"`
-	compiled       []*regexp.Regexp
+	TicketPatterns  []TicketPattern `json:"ticket_patterns"`
+	BranchCommands  []BranchCommand `json:"branch_commands"`
+	BranchTemplate  string          `json:"branch_template,omitempty"`
+	DefaultBase     string          `json:"default_base,omitempty"`
+	MaxLength       int             `json:"max_length,omitempty"`
+	Transliterate   string          `json:"transliterate,omitempty"`
+	TicketCase      string          `json:"ticket_case,omitempty"`
+	MultipleTickets bool            `json:"multiple_tickets,omitempty"`
+	GitBackend      string          `json:"git_backend,omitempty"`
+	CreateStrategy  string          `json:"create_strategy,omitempty"`
+	WorktreeDir     string          `json:"worktree_dir,omitempty"`
+	DirtyPolicy     string          `json:"dirty_policy,omitempty"`
+	Remote          string          `json:"remote,omitempty"`
+	AutoPush        bool            `json:"auto_push,omitempty"`
+	CheckRemote     bool            `json:"check_remote,omitempty"`
+	OnCollision     string          `json:"on_collision,omitempty"`
+	CollisionSuffix string          `json:"collision_suffix,omitempty"`
+	RequireTicket   bool            `json:"require_ticket,omitempty"`
+	LintIgnore      []string        `json:"lint_ignore,omitempty"`
+	compiled        []compiledPattern
+	problems        []Problem
+	sources         []string
 }
 
+// DefaultRemote is the remote branches are pushed to and checked against
+// when remote is not set.
+const DefaultRemote = "origin"
+
+// On collision values, for when the branch name is already taken locally or
+// on the remote.
+const (
+	CollisionError  = "error"
+	CollisionSwitch = "switch"
+	CollisionSuffix = "suffix"
+)
+
+// DefaultCollisionSuffix numbers a colliding name, e.g. feat/login-2.
+const DefaultCollisionSuffix = "-%d"
+
 func Default() *Config {
 	cfg := &Config{
-		TicketPatterns: []string{
-			`^#\d+$`,       // GitHub issues: #123
-			`^[A-Z]+-\d+$`, // Jira/Linear style: PIP-1234, INFRA-124
-			`^[A-Z]+_\d+$`, // Underscore variant: PIP_1234
+		TicketPatterns: []TicketPattern{
+			// GitHub issues: #123
+			{Pattern: `^#(?P<number>\d+)$`},
+			// Jira/Linear style: PIP-1234, INFRA-124
+			{Pattern: `^(?P<project>[A-Z]+)-(?P<number>\d+)$`},
+			// Underscore variant: PIP_1234, canonicalised to PIP-1234
+			{Pattern: `^(?P<project>[A-Z]+)_(?P<number>\d+)$`, Format: "${project}-${number}"},
 		},
-		BranchCommands: []string{
-			"feat",
-			"fix",
-			"tests",
-			"chore",
-			"docs",
+		BranchCommands: []BranchCommand{
+			{Name: "feat"},
+			{Name: "fix"},
+			{Name: "tests"},
+			{Name: "chore"},
+			{Name: "docs"},
 		},
+		// long-lived branches that don't follow the convention
+		LintIgnore: []string{"main", "master", "develop"},
 	}
-	cfg.compile()
+	// the patterns are compiled on first use, so they can still be changed
 	return cfg
 }
 
-func Load() (*Config, error) {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return Default(), nil
+// RemoteName returns the remote to push to and check for collisions.
+func (c *Config) RemoteName() string {
+	if c.Remote == "" {
+		return DefaultRemote
 	}
+	return c.Remote
+}
 
-	data, err := os.ReadFile(configPath)
+// repoConfigFiles are the locations, relative to the repository root and
+// without an extension, that are checked for a per-repository config. The
+// first one found, in any supported format, is used.
+var repoConfigFiles = []string{
+	".branch",
+	filepath.Join(".branch", "config"),
+}
+
+// Load builds the effective config by layering, from lowest to highest
+// precedence, the defaults, the user config, the [branch-tool] git config
+// section, the repository config and BRANCH_* environment variables. Objects
+// are merged key by key, any other value in a later layer replaces the
+// earlier one. The git config layer is read with gitConfig, and skipped if it
+// is nil.
+func Load(gitConfig GitConfig) (*Config, error) {
+	return load(true, gitConfig)
+}
+
+// LoadUser loads the user config over the defaults, ignoring any repository
+// config and environment overrides.
+func LoadUser() (*Config, error) {
+	return load(false, nil)
+}
+
+func load(effective bool, gitConfig GitConfig) (*Config, error) {
+	merged, err := toMap(Default())
 	if err != nil {
-		if os.IsNotExist(err) {
-			return Default(), nil
+		return nil, err
+	}
+
+	var sources []string
+	apply := func(layer map[string]any, source string) {
+		if layer != nil {
+			sources = append(sources, source)
+		}
+		deepMerge(merged, layer)
+	}
+
+	var problems []Problem
+	if configPath, err := getConfigPath(); err == nil {
+		layer, err := readLayer(configPath)
+		if err != nil {
+			return nil, err
+		}
+		apply(layer, configPath)
+
+		// only the default location may be missing; a file named with
+		// BRANCH_CONFIG is a problem, but not an error, so config init can
+		// still create it
+		if _, err := os.Stat(configPath); os.IsNotExist(err) && os.Getenv(envConfigPath) != "" {
+			problems = append(problems, Problem{Key: envConfigPath, Message: fmt.Sprintf("%s does not exist", configPath)})
+		}
+	}
+
+	if effective {
+		layer, names, err := gitConfigLayer(gitConfig)
+		if err != nil {
+			return nil, err
+		}
+		apply(layer, "git config ("+strings.Join(names, ", ")+")")
+
+		if repoPath := findRepoConfig(); repoPath != "" {
+			layer, err := readLayer(repoPath)
+			if err != nil {
+				return nil, err
+			}
+			apply(layer, repoPath)
+		}
+
+		layer, names, err = envLayer()
+		if err != nil {
+			return nil, err
 		}
+		apply(layer, "environment ("+strings.Join(names, ", ")+")")
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
 		return nil, err
 	}
 
@@ -57,9 +174,74 @@ func Load() (*Config, error) {
 	}
 
 	cfg.compile()
+	cfg.problems = append(problems, cfg.Validate()...)
+	cfg.sources = sources
 	return &cfg, nil
 }
 
+// Sources describes the config files and environment variables that were
+// merged to build the config, in the order they were applied. It is empty if
+// only the defaults were used.
+func (c *Config) Sources() []string {
+	return c.sources
+}
+
+// Path returns the location of the user config file, which can be set
+// explicitly with BRANCH_CONFIG. If there is no config file yet, this is
+// where a JSON one would be created.
+func Path() (string, error) {
+	return getConfigPath()
+}
+
+// readLayer reads a single config file into a generic map. A missing file is
+// not an error and yields an empty layer.
+func readLayer(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	layer, err := decode(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return layer, nil
+}
+
+func toMap(cfg *Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func deepMerge(dst, src map[string]any) {
+	for key, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcVal
+	}
+}
+
+// JSON returns the config as indented JSON, as Save writes it. Regular
+// expressions are written as they are, without escaping < and >.
+func (c *Config) JSON() ([]byte, error) {
+	return marshalJSON(c, "  ")
+}
+
 func (c *Config) Save() error {
 	configPath, err := getConfigPath()
 	if err != nil {
@@ -70,7 +252,17 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	// keep the format the user chose; JSON is marshalled directly so the
+	// keys stay in their declared order
+	var data []byte
+	if filepath.Ext(configPath) == ".json" {
+		data, err = c.JSON()
+	} else {
+		var m map[string]any
+		if m, err = toMap(c); err == nil {
+			data, err = encode(configPath, m)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -78,29 +270,126 @@ func (c *Config) Save() error {
 	return os.WriteFile(configPath, data, 0644)
 }
 
-func (c *Config) IsTicket(s string) bool {
-	if c.compiled == nil {
-		c.compile()
+// EditUser applies edit to the user config and writes back only the
+// settings it changed. The rest of the user's file, including YAML comments,
+// is left as it is, and defaults the user never set are not written into it.
+func EditUser(edit func(cfg *Config) error) error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	cfg, err := LoadUser()
+	if err != nil {
+		return err
+	}
+	before, err := toMap(cfg)
+	if err != nil {
+		return err
+	}
+	if err := edit(cfg); err != nil {
+		return err
+	}
+	after, err := toMap(cfg)
+	if err != nil {
+		return err
 	}
 
-	for _, re := range c.compiled {
-		if re.MatchString(s) {
-			return true
+	set := make(map[string]any)
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			set[key] = value
 		}
 	}
-	return false
+	var unset []string
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			// back to its zero value, which the default covers
+			unset = append(unset, key)
+		}
+	}
+
+	data, err = rewrite(configPath, data, set, unset)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, data, 0644)
+}
+
+func (c *Config) IsTicket(s string) bool {
+	return c.match(s) != nil
 }
 
 func (c *Config) compile() {
-	c.compiled = make([]*regexp.Regexp, 0, len(c.TicketPatterns))
+	c.compiled = make([]compiledPattern, 0, len(c.TicketPatterns))
 	for _, pattern := range c.TicketPatterns {
-		if re, err := regexp.Compile(pattern); err == nil {
-			c.compiled = append(c.compiled, re)
+		if re, err := regexp.Compile(pattern.Pattern); err == nil {
+			c.compiled = append(c.compiled, compiledPattern{re: re, pattern: pattern})
+		}
+	}
+}
+
+// findRepoConfig returns the path of the repository config for the git
+// repository containing the working directory, or an empty string if there
+// is none.
+func findRepoConfig() string {
+	root := findRepoRoot()
+	if root == "" {
+		return ""
+	}
+
+	for _, name := range repoConfigFiles {
+		if path := findConfigFile(filepath.Join(root, name)); path != "" {
+			return path
 		}
 	}
+	return ""
+}
+
+// findConfigFile returns the first existing file made of base plus one of the
+// supported extensions, or an empty string if there is none.
+func findConfigFile(base string) string {
+	for _, ext := range configExtensions {
+		path := base + ext
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// findRepoRoot walks up from the working directory looking for a .git entry,
+// which is a directory in normal clones and a file in worktrees.
+func findRepoRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
 }
 
 func getConfigPath() (string, error) {
+	if configPath := os.Getenv(envConfigPath); configPath != "" {
+		return configPath, nil
+	}
+
 	configDir := os.Getenv("XDG_CONFIG_HOME")
 	if configDir == "" {
 		home, err := os.UserHomeDir()
@@ -109,5 +398,9 @@ func getConfigPath() (string, error) {
 		}
 		configDir = filepath.Join(home, ".config")
 	}
-	return filepath.Join(configDir, "branch", "config.json"), nil
+	base := filepath.Join(configDir, "branch", "config")
+	if path := findConfigFile(base); path != "" {
+		return path, nil
+	}
+	return base + ".json", nil
 }
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/owenrumney/branch/internal/testutil"
 )
 
 func TestDefault(t *testing.T) {
@@ -17,9 +21,9 @@ func TestDefault(t *testing.T) {
 	}
 
 	expectedPatterns := []string{
-		`^#\d+$`,
-		`^[A-Z]+-\d+$`,
-		`^[A-Z]+_\d+$`,
+		`^#(?P<number>\d+)$`,
+		`^(?P<project>[A-Z]+)-(?P<number>\d+)$`,
+		`^(?P<project>[A-Z]+)_(?P<number>\d+)$`,
 	}
 
 	if len(cfg.TicketPatterns) != len(expectedPatterns) {
@@ -36,8 +40,8 @@ func TestDefault(t *testing.T) {
 			t.Errorf("Missing branch command: %q", expected)
 			continue
 		}
-		if cfg.BranchCommands[i] != expected {
-			t.Errorf("BranchCommands[%d] = %q, want %q", i, cfg.BranchCommands[i], expected)
+		if cfg.BranchCommands[i].Name != expected {
+			t.Errorf("BranchCommands[%d] = %q, want %q", i, cfg.BranchCommands[i].Name, expected)
 		}
 	}
 }
@@ -88,6 +92,8 @@ func TestIsTicket(t *testing.T) {
 }
 
 func TestLoad(t *testing.T) {
+	testutil.IsolateEnv(t)
+
 	// Test loading with non-existent file (should return default)
 	t.Run("non-existent file returns default", func(t *testing.T) {
 		// Temporarily set XDG_CONFIG_HOME to a non-existent path
@@ -97,15 +103,15 @@ func TestLoad(t *testing.T) {
 		testDir := t.TempDir()
 		_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(testDir, "nonexistent"))
 
-		cfg, err := Load()
+		cfg, err := Load(nil)
 		if err != nil {
-			t.Fatalf("Load() with non-existent file should not error, got: %v", err)
+			t.Fatalf("Load(nil) with non-existent file should not error, got: %v", err)
 		}
 		if cfg == nil {
-			t.Fatal("Load() should return default config")
+			t.Fatal("Load(nil) should return default config")
 		}
 		if len(cfg.TicketPatterns) == 0 {
-			t.Error("Load() should return config with patterns")
+			t.Error("Load(nil) should return config with patterns")
 		}
 	})
 
@@ -137,12 +143,12 @@ func TestLoad(t *testing.T) {
 		defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
 		_ = os.Setenv("XDG_CONFIG_HOME", testDir)
 
-		cfg, err := Load()
+		cfg, err := Load(nil)
 		if err != nil {
-			t.Fatalf("Load() with valid file should not error, got: %v", err)
+			t.Fatalf("Load(nil) with valid file should not error, got: %v", err)
 		}
 		if cfg == nil {
-			t.Fatal("Load() should return config")
+			t.Fatal("Load(nil) should return config")
 		}
 		if len(cfg.TicketPatterns) != 2 {
 			t.Errorf("Expected 2 patterns, got %d", len(cfg.TicketPatterns))
@@ -160,7 +166,7 @@ func TestLoad(t *testing.T) {
 		if len(cfg.BranchCommands) != 2 {
 			t.Errorf("Expected 2 branch commands, got %d", len(cfg.BranchCommands))
 		}
-		if cfg.BranchCommands[0] != "feat" || cfg.BranchCommands[1] != "fix" {
+		if cfg.BranchCommands[0].Name != "feat" || cfg.BranchCommands[1].Name != "fix" {
 			t.Errorf("Expected branch commands [feat, fix], got %v", cfg.BranchCommands)
 		}
 	})
@@ -185,12 +191,12 @@ func TestLoad(t *testing.T) {
 		defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
 		_ = os.Setenv("XDG_CONFIG_HOME", testDir)
 
-		cfg, err := Load()
+		cfg, err := Load(nil)
 		if err != nil {
-			t.Fatalf("Load() should not error, got: %v", err)
+			t.Fatalf("Load(nil) should not error, got: %v", err)
 		}
 		if len(cfg.TicketPatterns) == 0 {
-			t.Error("Load() should merge empty patterns with defaults")
+			t.Error("Load(nil) should merge empty patterns with defaults")
 		}
 	})
 
@@ -212,14 +218,16 @@ func TestLoad(t *testing.T) {
 		defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
 		_ = os.Setenv("XDG_CONFIG_HOME", testDir)
 
-		_, err := Load()
+		_, err := Load(nil)
 		if err == nil {
-			t.Error("Load() with invalid JSON should return error")
+			t.Error("Load(nil) with invalid JSON should return error")
 		}
 	})
 }
 
 func TestSave(t *testing.T) {
+	testutil.IsolateEnv(t)
+
 	testDir := t.TempDir()
 	configDir := filepath.Join(testDir, "branch")
 	configPath := filepath.Join(configDir, "config.json")
@@ -229,8 +237,8 @@ func TestSave(t *testing.T) {
 	_ = os.Setenv("XDG_CONFIG_HOME", testDir)
 
 	cfg := Default()
-	cfg.TicketPatterns = []string{`^TEST-\d+$`}
-	cfg.BranchCommands = []string{"custom", "command"}
+	cfg.TicketPatterns = []TicketPattern{{Pattern: `^TEST-\d+$`}}
+	cfg.BranchCommands = []BranchCommand{{Name: "custom"}, {Name: "command"}}
 
 	if err := cfg.Save(); err != nil {
 		t.Fatalf("Save() should not error, got: %v", err)
@@ -242,7 +250,7 @@ func TestSave(t *testing.T) {
 	}
 
 	// Load and verify
-	loaded, err := Load()
+	loaded, err := Load(nil)
 	if err != nil {
 		t.Fatalf("Failed to load saved config: %v", err)
 	}
@@ -251,25 +259,73 @@ func TestSave(t *testing.T) {
 		t.Errorf("Expected 1 pattern, got %d", len(loaded.TicketPatterns))
 	}
 
-	if loaded.TicketPatterns[0] != `^TEST-\d+$` {
-		t.Errorf("Expected pattern ^TEST-\\d+$, got %q", loaded.TicketPatterns[0])
+	if loaded.TicketPatterns[0].Pattern != `^TEST-\d+$` {
+		t.Errorf("Expected pattern ^TEST-\\d+$, got %q", loaded.TicketPatterns[0].Pattern)
 	}
 
 	// Verify branch commands are saved and loaded
 	if len(loaded.BranchCommands) != 2 {
 		t.Errorf("Expected 2 branch commands, got %d", len(loaded.BranchCommands))
 	}
-	if loaded.BranchCommands[0] != "custom" || loaded.BranchCommands[1] != "command" {
+	if loaded.BranchCommands[0].Name != "custom" || loaded.BranchCommands[1].Name != "command" {
 		t.Errorf("Expected branch commands [custom, command], got %v", loaded.BranchCommands)
 	}
 }
 
+func TestEditUser(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	testDir := t.TempDir()
+	configPath := filepath.Join(testDir, "config.json")
+	t.Setenv(envConfigPath, configPath)
+
+	if err := os.WriteFile(configPath, []byte(`{"max_length": 40}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := EditUser(func(cfg *Config) error {
+		cfg.BranchCommands = append(cfg.BranchCommands, BranchCommand{Name: "hotfix"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EditUser() unexpected error: %v", err)
+	}
+
+	layer, err := readLayer(configPath)
+	if err != nil {
+		t.Fatalf("readLayer() unexpected error: %v", err)
+	}
+	// only the user's own setting and the edited one, no defaults
+	keys := slices.Sorted(maps.Keys(layer))
+	if !slices.Equal(keys, []string{"branch_commands", "max_length"}) {
+		t.Errorf("config file keys = %v, want [branch_commands max_length]", keys)
+	}
+
+	loaded, err := LoadUser()
+	if err != nil {
+		t.Fatalf("LoadUser() unexpected error: %v", err)
+	}
+	if loaded.MaxLength != 40 || !slices.Contains(loaded.CommandNames(), "hotfix") || !slices.Contains(loaded.CommandNames(), "feat") {
+		t.Errorf("LoadUser() = max_length %d, commands %v, want 40 and the defaults plus hotfix", loaded.MaxLength, loaded.CommandNames())
+	}
+
+	t.Run("failed edit writes nothing", func(t *testing.T) {
+		before, _ := os.ReadFile(configPath)
+		if err := EditUser(func(cfg *Config) error { return os.ErrInvalid }); err == nil {
+			t.Fatal("EditUser() should return the edit's error")
+		}
+		if after, _ := os.ReadFile(configPath); string(after) != string(before) {
+			t.Errorf("config file changed after a failed edit:\n%s", after)
+		}
+	})
+}
+
 func TestCompile(t *testing.T) {
 	cfg := &Config{
-		TicketPatterns: []string{
-			`^#\d+$`,
-			`invalid[regex`, // This should be skipped
-			`^[A-Z]+-\d+$`,
+		TicketPatterns: []TicketPattern{
+			{Pattern: `^#\d+$`},
+			{Pattern: `invalid[regex`}, // This should be skipped
+			{Pattern: `^[A-Z]+-\d+$`},
 		},
 	}
 
@@ -288,3 +344,120 @@ func TestCompile(t *testing.T) {
 		t.Error("Pattern should match PIP-123")
 	}
 }
+
+func TestLoadRepoConfig(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	writeFile := func(t *testing.T, path, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	setup := func(t *testing.T) (userDir, repoDir string) {
+		t.Helper()
+		userDir = t.TempDir()
+		repoDir = t.TempDir()
+		if err := os.Mkdir(filepath.Join(repoDir, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create .git dir: %v", err)
+		}
+
+		oldEnv := os.Getenv("XDG_CONFIG_HOME")
+		t.Cleanup(func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) })
+		_ = os.Setenv("XDG_CONFIG_HOME", userDir)
+
+		oldWd, err := os.Getwd()
+		if err != nil {
+			t.Fatalf("Failed to get working directory: %v", err)
+		}
+		t.Cleanup(func() { _ = os.Chdir(oldWd) })
+		return userDir, repoDir
+	}
+
+	t.Run("repo config is merged over user config", func(t *testing.T) {
+		userDir, repoDir := setup(t)
+		writeFile(t, filepath.Join(userDir, "branch", "config.json"), `{"branch_commands": ["feat", "fix"], "branch_template": "{{.Type}}/{{.Slug}}"}`)
+		writeFile(t, filepath.Join(repoDir, ".branch.json"), `{"ticket_patterns": ["^TEAM-\\d+$"], "branch_commands": ["story"]}`)
+
+		subDir := filepath.Join(repoDir, "pkg", "sub")
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			t.Fatalf("Failed to create sub dir: %v", err)
+		}
+		if err := os.Chdir(subDir); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+
+		if len(cfg.BranchCommands) != 1 || cfg.BranchCommands[0].Name != "story" {
+			t.Errorf("Expected repo branch commands [story], got %v", cfg.BranchCommands)
+		}
+		wantSources := []string{filepath.Join(userDir, "branch", "config.json"), filepath.Join(repoDir, ".branch.json")}
+		if !slices.Equal(cfg.Sources(), wantSources) {
+			t.Errorf("Sources() = %v, want %v", cfg.Sources(), wantSources)
+		}
+		if cfg.BranchTemplate != "{{.Type}}/{{.Slug}}" {
+			t.Errorf("Expected user branch template to be kept, got %q", cfg.BranchTemplate)
+		}
+		if !cfg.IsTicket("TEAM-1") || cfg.IsTicket("PIP-1") {
+			t.Error("Expected repo ticket patterns to replace user patterns")
+		}
+	})
+
+	t.Run("repo config in .branch directory", func(t *testing.T) {
+		_, repoDir := setup(t)
+		writeFile(t, filepath.Join(repoDir, ".branch", "config.json"), `{"branch_commands": ["story"]}`)
+		if err := os.Chdir(repoDir); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		if len(cfg.BranchCommands) != 1 || cfg.BranchCommands[0].Name != "story" {
+			t.Errorf("Expected repo branch commands [story], got %v", cfg.BranchCommands)
+		}
+		if len(cfg.TicketPatterns) != len(Default().TicketPatterns) {
+			t.Errorf("Expected default ticket patterns, got %v", cfg.TicketPatterns)
+		}
+	})
+
+	t.Run("LoadUser ignores repo config", func(t *testing.T) {
+		_, repoDir := setup(t)
+		writeFile(t, filepath.Join(repoDir, ".branch.json"), `{"branch_commands": ["story"]}`)
+		if err := os.Chdir(repoDir); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+
+		cfg, err := LoadUser()
+		if err != nil {
+			t.Fatalf("LoadUser() should not error, got: %v", err)
+		}
+		if len(cfg.BranchCommands) != len(Default().BranchCommands) {
+			t.Errorf("Expected default branch commands, got %v", cfg.BranchCommands)
+		}
+		if len(cfg.Sources()) != 0 {
+			t.Errorf("Expected no sources, got %v", cfg.Sources())
+		}
+	})
+
+	t.Run("invalid repo config returns error", func(t *testing.T) {
+		_, repoDir := setup(t)
+		writeFile(t, filepath.Join(repoDir, ".branch.json"), `{ invalid json }`)
+		if err := os.Chdir(repoDir); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+
+		if _, err := Load(nil); err == nil {
+			t.Error("Load(nil) with invalid repo config should return error")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envPrefix is prepended to the upper-cased config key to form the name
+	// of the variable that overrides it, e.g. BRANCH_MAX_LENGTH.
+	envPrefix = "BRANCH_"
+	// envConfigPath points at an explicit user config file.
+	envConfigPath = "BRANCH_CONFIG"
+)
+
+// regexpLists are list settings of regular expressions. Commas are common
+// in those, e.g. in {2,5}, so they are only split on newlines.
+var regexpLists = map[string]bool{
+	"ticket_patterns": true,
+}
+
+// envLayer builds a config layer from BRANCH_* environment variables, one per
+// config key. Lists are comma separated, newline separated for regexpLists,
+// or a JSON array when the value starts with '['. It also returns the names
+// of the variables that were set.
+func envLayer() (map[string]any, []string, error) {
+	layer := make(map[string]any)
+	var names []string
+
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		name := envPrefix + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		separator := ","
+		if regexpLists[key] {
+			separator = "\n"
+		}
+		parsed, err := parseEnvValue(field.Type, value, separator)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		layer[key] = parsed
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+	return layer, names, nil
+}
+
+// parseEnvValue converts a string setting to the type of its config field.
+// Lists that aren't JSON arrays are split on separator.
+func parseEnvValue(t reflect.Type, value, separator string) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", value)
+		}
+		return n, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", value)
+		}
+		return b, nil
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var list []any
+			if err := json.Unmarshal([]byte(value), &list); err != nil {
+				return nil, fmt.Errorf("invalid JSON list: %w", err)
+			}
+			return list, nil
+		}
+
+		list := []any{}
+		for _, item := range strings.Split(value, separator) {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("unsupported setting type %s", t)
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/testutil"
+)
+
+func TestLoadEnv(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	setEnv := func(t *testing.T, key, value string) {
+		t.Helper()
+		old, had := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if had {
+				_ = os.Setenv(key, old)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+		_ = os.Setenv(key, value)
+	}
+
+	writeUserConfig := func(t *testing.T, data string) string {
+		t.Helper()
+		testDir := t.TempDir()
+		configDir := filepath.Join(testDir, "branch")
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			t.Fatalf("Failed to create config dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		setEnv(t, "XDG_CONFIG_HOME", testDir)
+		return testDir
+	}
+
+	t.Run("environment overrides config file", func(t *testing.T) {
+		writeUserConfig(t, `{"max_length": 40, "default_base": "main", "branch_commands": ["feat"]}`)
+		setEnv(t, "BRANCH_MAX_LENGTH", "63")
+		setEnv(t, "BRANCH_DEFAULT_BASE", "origin/main")
+		setEnv(t, "BRANCH_TICKET_PATTERNS", "^OPS-\\d+$\n^#\\d+$")
+		setEnv(t, "BRANCH_MULTIPLE_TICKETS", "true")
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+
+		if cfg.MaxLength != 63 {
+			t.Errorf("MaxLength = %d, want 63", cfg.MaxLength)
+		}
+		if cfg.DefaultBase != "origin/main" {
+			t.Errorf("DefaultBase = %q, want origin/main", cfg.DefaultBase)
+		}
+		if !cfg.IsTicket("OPS-1") || !cfg.IsTicket("#1") || cfg.IsTicket("PIP-1") {
+			t.Errorf("Expected env ticket patterns, got %v", cfg.TicketPatterns)
+		}
+		if !cfg.MultipleTickets {
+			t.Error("MultipleTickets should be set from the environment")
+		}
+		if !slices.Equal(cfg.CommandNames(), []string{"feat"}) {
+			t.Errorf("Expected branch commands from file, got %v", cfg.CommandNames())
+		}
+	})
+
+	t.Run("ticket patterns are not split on commas", func(t *testing.T) {
+		writeUserConfig(t, `{}`)
+		setEnv(t, "BRANCH_TICKET_PATTERNS", `^[A-Z]{2,5}-\d+$`)
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		if len(cfg.TicketPatterns) != 1 || !cfg.IsTicket("OPS-1") || cfg.IsTicket("ABCDEFG-1") {
+			t.Errorf("Expected a single {2,5} pattern, got %v", cfg.TicketPatterns)
+		}
+	})
+
+	t.Run("JSON list values", func(t *testing.T) {
+		writeUserConfig(t, `{}`)
+		setEnv(t, "BRANCH_BRANCH_COMMANDS", `["fix", {"name": "feature", "prefix": "feat"}]`)
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		if command, ok := cfg.FindCommand("feature"); !ok || command.BranchType() != "feat" {
+			t.Errorf("Expected feature command from env, got %v", cfg.BranchCommands)
+		}
+	})
+
+	t.Run("invalid value returns error", func(t *testing.T) {
+		writeUserConfig(t, `{}`)
+		setEnv(t, "BRANCH_MAX_LENGTH", "long")
+
+		if _, err := Load(nil); err == nil {
+			t.Error("Load(nil) with invalid BRANCH_MAX_LENGTH should return error")
+		}
+	})
+
+	t.Run("LoadUser ignores environment", func(t *testing.T) {
+		writeUserConfig(t, `{"max_length": 40}`)
+		setEnv(t, "BRANCH_MAX_LENGTH", "63")
+
+		cfg, err := LoadUser()
+		if err != nil {
+			t.Fatalf("LoadUser() should not error, got: %v", err)
+		}
+		if cfg.MaxLength != 40 {
+			t.Errorf("MaxLength = %d, want 40", cfg.MaxLength)
+		}
+	})
+
+	t.Run("BRANCH_CONFIG selects the config file", func(t *testing.T) {
+		writeUserConfig(t, `{"max_length": 40}`)
+		explicit := filepath.Join(t.TempDir(), "team.yaml")
+		if err := os.WriteFile(explicit, []byte("max_length: 50\n"), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		setEnv(t, "BRANCH_CONFIG", explicit)
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		if cfg.MaxLength != 50 {
+			t.Errorf("MaxLength = %d, want 50", cfg.MaxLength)
+		}
+		if path, _ := Path(); path != explicit {
+			t.Errorf("Path() = %q, want %q", path, explicit)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// configExtensions are the supported config file formats in order of
+// precedence: when several files exist in the same place, the first wins.
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// decode parses config file data into a generic map, choosing the format
+// from the file extension.
+func decode(path string, data []byte) (map[string]any, error) {
+	var m map[string]any
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+
+	return m, nil
+}
+
+// encode writes a generic map in the format matching the file extension.
+func encode(path string, m map[string]any) ([]byte, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return marshalJSON(m, "  ")
+	case ".yaml", ".yml":
+		return yaml.Marshal(m)
+	case ".toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+}
+
+// rewrite sets and removes top-level keys in a config file's data, keeping
+// the rest of the file as it is. YAML is edited as a node tree so comments
+// survive, except inside the values being replaced. TOML is decoded and
+// encoded again, which keeps the values but loses comments and layout.
+func rewrite(path string, data []byte, set map[string]any, unset []string) ([]byte, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return rewriteJSON(data, set, unset)
+	case ".yaml", ".yml":
+		return rewriteYAML(data, set, unset)
+	case ".toml":
+		m := make(map[string]any)
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		maps.Copy(m, set)
+		for _, key := range unset {
+			delete(m, key)
+		}
+		return encode(path, m)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+}
+
+// KeepsComments reports whether editing the config file at path keeps its
+// comments. TOML files are written back from their values, so they don't.
+func KeepsComments(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) != ".toml"
+}
+
+// rewriteJSON keeps the keys of the top-level object in the order they were
+// written, and any value that isn't replaced exactly as it was.
+func rewriteJSON(data []byte, set map[string]any, unset []string) ([]byte, error) {
+	type entry struct {
+		key   string
+		value json.RawMessage
+	}
+	var entries []entry
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if tok, err := dec.Token(); err != nil {
+			return nil, err
+		} else if tok != json.Delim('{') {
+			return nil, fmt.Errorf("expected an object at the top level")
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{key: tok.(string), value: value})
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		value, err := marshalJSON(set[key], "")
+		if err != nil {
+			return nil, err
+		}
+		if i := slices.IndexFunc(entries, func(e entry) bool { return e.key == key }); i >= 0 {
+			entries[i].value = value
+			continue
+		}
+		entries = append(entries, entry{key: key, value: value})
+	}
+	entries = slices.DeleteFunc(entries, func(e entry) bool { return slices.Contains(unset, e.key) })
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := marshalJSON(e.key, "")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n  ")
+		buf.Write(key)
+		buf.WriteString(": ")
+		if err := json.Indent(&buf, e.value, "  ", "  "); err != nil {
+			return nil, err
+		}
+	}
+	if len(entries) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal, indented if indent is set, without escaping
+// <, > and &, which are common in regular expressions such as (?P<number>\d+).
+func marshalJSON(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func rewriteYAML(data []byte, set map[string]any, unset []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		// an empty file, or only comments, which the parser drops
+		out, err := yaml.Marshal(set)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+			data = append(data, '\n')
+		}
+		return append(data, out...), nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	// keys alternate with their values in Content
+	index := func(key string) int {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		var value yaml.Node
+		if err := value.Encode(set[key]); err != nil {
+			return nil, err
+		}
+		if i := index(key); i >= 0 {
+			value.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = &value
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &value)
+	}
+	for _, key := range unset {
+		if i := index(key); i >= 0 {
+			root.Content = slices.Delete(root.Content, i, i+2)
+		}
+	}
+
+	// two spaces, as hand-written YAML usually is, rather than the default four
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/testutil"
+)
+
+func TestLoadFormats(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			data: `# only our Jira projects
+ticket_patterns:
+  - "^CUSTOM-\\d+$"
+  - pattern: "^(?P<project>OPS)_(?P<number>\\d+)$"
+    format: "${project}-${number}"
+branch_commands: [feat, fix]
+max_length: 40
+`,
+		},
+		{
+			name: "yml",
+			file: "config.yml",
+			data: `ticket_patterns: ["^CUSTOM-\\d+$", {pattern: "^(?P<project>OPS)_(?P<number>\\d+)$", format: "${project}-${number}"}]
+branch_commands: [feat, fix]
+max_length: 40
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			data: `# only our Jira projects
+ticket_patterns = ['^CUSTOM-\d+$', { pattern = '^(?P<project>OPS)_(?P<number>\d+)$', format = '${project}-${number}' }]
+branch_commands = ["feat", "fix"]
+max_length = 40
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDir := t.TempDir()
+			configDir := filepath.Join(testDir, "branch")
+			if err := os.MkdirAll(configDir, 0755); err != nil {
+				t.Fatalf("Failed to create config dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(configDir, tt.file), []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			oldEnv := os.Getenv("XDG_CONFIG_HOME")
+			defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+			_ = os.Setenv("XDG_CONFIG_HOME", testDir)
+
+			cfg, err := Load(nil)
+			if err != nil {
+				t.Fatalf("Load(nil) should not error, got: %v", err)
+			}
+			if !cfg.IsTicket("CUSTOM-1") {
+				t.Error("Expected pattern to match CUSTOM-1")
+			}
+			if ticket, ok := cfg.ParseTicket("OPS_7"); !ok || ticket.Key != "OPS-7" {
+				t.Errorf("Expected OPS_7 to be canonicalised to OPS-7, got %+v", ticket)
+			}
+			if len(cfg.BranchCommands) != 2 {
+				t.Errorf("Expected 2 branch commands, got %v", cfg.BranchCommands)
+			}
+			if cfg.MaxLength != 40 {
+				t.Errorf("Expected max_length 40, got %d", cfg.MaxLength)
+			}
+
+			// Save should keep the file in the same format
+			cfg.BranchCommands = append(cfg.BranchCommands, BranchCommand{Name: "hotfix"})
+			if err := cfg.Save(); err != nil {
+				t.Fatalf("Save() should not error, got: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(configDir, "config.json")); !os.IsNotExist(err) {
+				t.Error("Save() should not create a JSON config")
+			}
+
+			loaded, err := Load(nil)
+			if err != nil {
+				t.Fatalf("Load(nil) after Save() should not error, got: %v", err)
+			}
+			if len(loaded.BranchCommands) != 3 || loaded.BranchCommands[2].Name != "hotfix" {
+				t.Errorf("Expected saved branch commands, got %v", loaded.BranchCommands)
+			}
+			if ticket, ok := loaded.ParseTicket("OPS_7"); !ok || ticket.Key != "OPS-7" {
+				t.Errorf("Expected saved pattern format to survive, got %+v", ticket)
+			}
+		})
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	testDir := t.TempDir()
+	configDir := filepath.Join(testDir, "branch")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	for _, name := range []string{"config.toml", "config.yaml"} {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(""), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	oldEnv := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", testDir)
+
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("Path() should not error, got: %v", err)
+	}
+	if !strings.HasSuffix(path, "config.yaml") {
+		t.Errorf("Path() = %q, want config.yaml to take precedence over config.toml", path)
+	}
+}
+
+func TestEditUserFormats(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	addHotfix := func(cfg *Config) error {
+		cfg.BranchCommands = append(cfg.BranchCommands, BranchCommand{Name: "hotfix"})
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		file string
+		data string
+		// want are lines the file must contain after the edit
+		want []string
+	}{
+		{
+			name: "yaml keeps comments",
+			file: "config.yaml",
+			data: `# only our Jira projects
+ticket_patterns:
+  - "^CUSTOM-\\d+$"
+# keep names short for the CI
+max_length: 40 # characters
+`,
+			want: []string{"# only our Jira projects", "# keep names short for the CI", "max_length: 40 # characters", "- hotfix"},
+		},
+		{
+			name: "yaml with only a comment",
+			file: "config.yml",
+			data: "# branch settings\n",
+			want: []string{"# branch settings", "- hotfix"},
+		},
+		{
+			name: "json keeps key order",
+			file: "config.json",
+			data: `{
+  "max_length": 40,
+  "default_base": "origin/main",
+  "ticket_patterns": ["^(?P<project>OPS)-(?P<number>\\d+)$"]
+}`,
+			want: []string{`"max_length": 40,
+  "default_base": "origin/main",
+  "ticket_patterns": [
+    "^(?P<project>OPS)-(?P<number>\\d+)$"
+  ],
+  "branch_commands": [`},
+		},
+		{
+			name: "toml keeps its settings",
+			file: "config.toml",
+			data: "# only our Jira projects\nmax_length = 40\n",
+			want: []string{"max_length = 40", "hotfix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.file)
+			t.Setenv(envConfigPath, configPath)
+			if err := os.WriteFile(configPath, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := EditUser(addHotfix); err != nil {
+				t.Fatalf("EditUser() unexpected error: %v", err)
+			}
+
+			data, _ := os.ReadFile(configPath)
+			for _, line := range tt.want {
+				if !strings.Contains(string(data), line) {
+					t.Errorf("config file should contain %q:\n%s", line, data)
+				}
+			}
+			// the new setting is added without touching the rest of the file
+			if strings.HasPrefix(tt.file, "config.y") && !strings.HasPrefix(string(data), tt.data) {
+				t.Errorf("config file should start with the original content:\n%s", data)
+			}
+
+			cfg, err := LoadUser()
+			if err != nil {
+				t.Fatalf("LoadUser() unexpected error: %v", err)
+			}
+			if _, ok := cfg.FindCommand("hotfix"); !ok {
+				t.Errorf("hotfix should be added, got %v", cfg.CommandNames())
+			}
+		})
+	}
+}
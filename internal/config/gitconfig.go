@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/owenrumney/branch/internal/git"
+)
+
+// gitConfigSection is the git config section read for settings. The branch
+// section belongs to git itself, so the tool uses its own.
+const gitConfigSection = "branch-tool"
+
+// gitConfigAliases maps shorter git config names onto config keys. Every
+// key can also be set by its camelCase name, e.g. maxLength for max_length.
+var gitConfigAliases = map[string]string{
+	"commands":      "branch_commands",
+	"command":       "branch_commands",
+	"ticketpattern": "ticket_patterns",
+	"template":      "branch_template",
+}
+
+// GitConfig reads the [branch-tool] section of git config. It is implemented
+// by git.Repository, so git config is read with the chosen git_backend.
+type GitConfig interface {
+	ConfigSection(section string) ([]git.ConfigEntry, error)
+}
+
+// gitConfigLayer reads settings from the [branch-tool] section of git config,
+// so they can be scoped to a directory tree with includeIf. List settings are
+// multi-valued keys, with one entry per value. It also returns the git config
+// names that were set.
+func gitConfigLayer(gitConfig GitConfig) (map[string]any, []string, error) {
+	if gitConfig == nil {
+		return nil, nil, nil
+	}
+	entries, err := gitConfig.ConfigSection(gitConfigSection)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading git config: %w", err)
+	}
+
+	fields := make(map[string]reflect.Type)
+	keys := make(map[string]string)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		fields[key] = t.Field(i).Type
+		keys[strings.ReplaceAll(key, "_", "")] = key
+	}
+	for alias, key := range gitConfigAliases {
+		keys[alias] = key
+	}
+
+	layer := make(map[string]any)
+	var names []string
+	for _, entry := range entries {
+		name, value := entry.Name, entry.Value
+
+		// git reports names lower-cased
+		key, ok := keys[strings.TrimPrefix(name, gitConfigSection+".")]
+		if !ok {
+			continue
+		}
+		// the backend reads git config, so it can't be chosen there
+		if key == "git_backend" {
+			continue
+		}
+
+		if fields[key].Kind() == reflect.Slice {
+			list, _ := layer[key].([]any)
+			layer[key] = append(list, value)
+		} else {
+			parsed, err := parseEnvValue(fields[key], value, ",")
+			if err != nil {
+				return nil, nil, fmt.Errorf("git config %s: %w", name, err)
+			}
+			layer[key] = parsed
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+	return layer, names, nil
+}
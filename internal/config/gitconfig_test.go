@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/git"
+	"github.com/owenrumney/branch/internal/testutil"
+)
+
+// gitConfigEntries is a GitConfig that returns fixed entries.
+type gitConfigEntries []git.ConfigEntry
+
+func (e gitConfigEntries) ConfigSection(string) ([]git.ConfigEntry, error) {
+	return e, nil
+}
+
+func TestLoadGitConfig(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	testDir := t.TempDir()
+	oldEnv := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(testDir, "xdg"))
+
+	// run outside any repository so no repository config is read
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(oldWd) }()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	cfg, err := Load(gitConfigEntries{
+		{Name: "branch-tool.commands", Value: "feat"},
+		{Name: "branch-tool.commands", Value: "fix"},
+		{Name: "branch-tool.ticketpattern", Value: `^OPS-\d+$`},
+		{Name: "branch-tool.maxlength", Value: "50"},
+		{Name: "branch-tool.defaultbase", Value: "origin/main"},
+		{Name: "branch-tool.multipletickets", Value: "true"},
+		{Name: "branch-tool.gitbackend", Value: "exec"},
+	})
+	if err != nil {
+		t.Fatalf("Load() should not error, got: %v", err)
+	}
+
+	if !slices.Equal(cfg.CommandNames(), []string{"feat", "fix"}) {
+		t.Errorf("Expected commands [feat fix], got %v", cfg.CommandNames())
+	}
+	if !cfg.IsTicket("OPS-1") || cfg.IsTicket("PIP-1") {
+		t.Errorf("Expected git config ticket pattern, got %v", cfg.TicketPatterns)
+	}
+	if cfg.MaxLength != 50 {
+		t.Errorf("MaxLength = %d, want 50", cfg.MaxLength)
+	}
+	if cfg.DefaultBase != "origin/main" {
+		t.Errorf("DefaultBase = %q, want origin/main", cfg.DefaultBase)
+	}
+	if !cfg.MultipleTickets {
+		t.Error("MultipleTickets should be set from git config")
+	}
+	if cfg.GitBackend != "" {
+		t.Errorf("GitBackend = %q, git config should not choose the backend", cfg.GitBackend)
+	}
+	if len(cfg.Sources()) != 1 {
+		t.Errorf("Expected git config to be listed as a source, got %v", cfg.Sources())
+	}
+
+	t.Run("fake repository", func(t *testing.T) {
+		repo := git.NewFake()
+		repo.Settings["branch-tool.maxLength"] = "40"
+		cfg, err := Load(repo)
+		if err != nil {
+			t.Fatalf("Load() should not error, got: %v", err)
+		}
+		if cfg.MaxLength != 40 {
+			t.Errorf("MaxLength = %d, want 40", cfg.MaxLength)
+		}
+	})
+
+	t.Run("invalid value returns error", func(t *testing.T) {
+		if _, err := Load(gitConfigEntries{{Name: "branch-tool.maxlength", Value: "long"}}); err == nil {
+			t.Error("Load() with invalid git config value should return error")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// Ticket case values for ticket_case and per-pattern overrides.
+const (
+	TicketCaseLower    = "lower"
+	TicketCaseUpper    = "upper"
+	TicketCasePreserve = "preserve"
+)
+
+// TicketPattern is a regular expression that recognises a ticket reference.
+// In config files it is either a plain pattern string or an object:
+//
+//	{"pattern": "^(?P<project>[A-Z]+)_(?P<number>\\d+)$", "format": "${project}-${number}", "case": "upper"}
+//
+// The named groups project and number are exposed on the parsed Ticket, and
+// Format rewrites the match into its canonical key using regexp.Expand syntax.
+type TicketPattern struct {
+	Pattern string `json:"pattern"`
+	// Format is the template for the canonical ticket key, e.g. "${project}-${number}".
+	Format string `json:"format,omitempty"`
+	// Case overrides ticket_case for tickets matched by this pattern.
+	Case string `json:"case,omitempty"`
+	// Bare lets a ticket starting with # be read back from a branch name
+	// without it, e.g. #123 from feat/123-add-login. It is off by default, as
+	// a description starting with a number would be taken for a ticket.
+	Bare bool `json:"bare,omitempty"`
+}
+
+// Ticket is a ticket reference recognised by one of the ticket patterns.
+type Ticket struct {
+	// Raw is the ticket as it was written.
+	Raw string
+	// Project and Number are the values of the project and number named
+	// groups, if the pattern has them.
+	Project string
+	Number  string
+	// Key is the canonical form of the ticket, built from the pattern's format
+	// or Raw if it has none.
+	Key string
+	// Case is how the ticket should be cased in branch names.
+	Case string
+}
+
+type compiledPattern struct {
+	re      *regexp.Regexp
+	pattern TicketPattern
+}
+
+func (p *TicketPattern) UnmarshalJSON(data []byte) error {
+	var pattern string
+	if err := json.Unmarshal(data, &pattern); err == nil {
+		*p = TicketPattern{Pattern: pattern}
+		return nil
+	}
+
+	type plain TicketPattern
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*p = TicketPattern(obj)
+	return nil
+}
+
+// MarshalJSON writes a pattern without a case, format or bare option as just
+// its regular expression, the way the default patterns are written.
+func (p TicketPattern) MarshalJSON() ([]byte, error) {
+	if p.Case == "" && p.Format == "" && !p.Bare {
+		return marshalJSON(p.Pattern, "")
+	}
+
+	type plain TicketPattern
+	return marshalJSON(plain(p), "")
+}
+
+// ParseTicket matches s against the ticket patterns, returning the structured
+// ticket from the first pattern that matches.
+func (c *Config) ParseTicket(s string) (Ticket, bool) {
+	match := c.match(s)
+	if match == nil {
+		return Ticket{}, false
+	}
+
+	ticket := Ticket{Raw: s, Key: s, Case: c.TicketCase}
+	if match.pattern.Case != "" {
+		ticket.Case = match.pattern.Case
+	}
+
+	submatches := match.re.FindStringSubmatchIndex(s)
+	if i := match.re.SubexpIndex("project"); i > 0 && submatches[2*i] >= 0 {
+		ticket.Project = s[submatches[2*i]:submatches[2*i+1]]
+	}
+	if i := match.re.SubexpIndex("number"); i > 0 && submatches[2*i] >= 0 {
+		ticket.Number = s[submatches[2*i]:submatches[2*i+1]]
+	}
+	if match.pattern.Format != "" {
+		ticket.Key = string(match.re.ExpandString(nil, match.pattern.Format, s, submatches))
+	}
+
+	return ticket, true
+}
+
+// IsBareTicket reports whether ticket matches a pattern with bare set, so
+// branch names may carry it without its leading #.
+func (c *Config) IsBareTicket(ticket string) bool {
+	match := c.match(ticket)
+	return match != nil && match.pattern.Bare
+}
+
+func (c *Config) match(s string) *compiledPattern {
+	if c.compiled == nil {
+		c.compile()
+	}
+
+	for i := range c.compiled {
+		if c.compiled[i].re.MatchString(s) {
+			return &c.compiled[i]
+		}
+	}
+	return nil
+}
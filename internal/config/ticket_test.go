@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTicketPatternJSON(t *testing.T) {
+	data := `{"ticket_patterns": ["^#\\d+$", {"pattern": "^[A-Z]+-\\d+$", "case": "preserve"}, {"pattern": "^GH#\\d+$", "bare": true}]}`
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal() should not error, got: %v", err)
+	}
+
+	want := []TicketPattern{
+		{Pattern: `^#\d+$`},
+		{Pattern: `^[A-Z]+-\d+$`, Case: TicketCasePreserve},
+		{Pattern: `^GH#\d+$`, Bare: true},
+	}
+	if len(cfg.TicketPatterns) != len(want) {
+		t.Fatalf("Expected %d patterns, got %d", len(want), len(cfg.TicketPatterns))
+	}
+	for i := range want {
+		if cfg.TicketPatterns[i] != want[i] {
+			t.Errorf("TicketPatterns[%d] = %+v, want %+v", i, cfg.TicketPatterns[i], want[i])
+		}
+	}
+
+	out, err := json.Marshal(cfg.TicketPatterns)
+	if err != nil {
+		t.Fatalf("Marshal() should not error, got: %v", err)
+	}
+	if string(out) != `["^#\\d+$",{"pattern":"^[A-Z]+-\\d+$","case":"preserve"},{"pattern":"^GH#\\d+$","bare":true}]` {
+		t.Errorf("Marshal() = %s", out)
+	}
+}
+
+func TestParseTicket(t *testing.T) {
+	cfg := Default()
+	cfg.TicketPatterns = append(cfg.TicketPatterns, TicketPattern{
+		Pattern: `^(?P<project>[a-z]+)/(?P<number>\d+)$`,
+		Format:  "${project}-${number}",
+		Case:    TicketCaseUpper,
+	})
+	cfg.compile()
+
+	tests := []struct {
+		input  string
+		want   Ticket
+		wantOK bool
+	}{
+		{"PIP-1234", Ticket{Raw: "PIP-1234", Project: "PIP", Number: "1234", Key: "PIP-1234"}, true},
+		{"PIP_1234", Ticket{Raw: "PIP_1234", Project: "PIP", Number: "1234", Key: "PIP-1234"}, true},
+		{"#42", Ticket{Raw: "#42", Number: "42", Key: "#42"}, true},
+		{"pip/7", Ticket{Raw: "pip/7", Project: "pip", Number: "7", Key: "pip-7", Case: TicketCaseUpper}, true},
+		{"not-a-ticket", Ticket{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := cfg.ParseTicket(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseTicket(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTicket(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/owenrumney/branch/internal/branch"
+	"github.com/owenrumney/branch/internal/git"
+)
+
+// Problem is something wrong with the config, identified by the key it was
+// found under, e.g. ticket_patterns[1].
+type Problem struct {
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Key, p.Message)
+}
+
+// builtinCommands are the commands provided by branch itself, which a branch
+// command with the same name would collide with.
+var builtinCommands = []string{"help", "completion", "name", "list", "current", "lint", "hooks", "config"}
+
+var collisionPolicies = []string{CollisionError, CollisionSwitch, CollisionSuffix}
+
+var ticketCases = []string{TicketCaseLower, TicketCaseUpper, TicketCasePreserve}
+
+// Problems returns the problems found when the config was loaded.
+func (c *Config) Problems() []Problem {
+	return c.problems
+}
+
+// Validate checks the config for mistakes that would otherwise be silently
+// ignored, such as ticket patterns that are not valid regular expressions.
+func (c *Config) Validate() []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, pattern := range c.TicketPatterns {
+		key := fmt.Sprintf("ticket_patterns[%d]", i)
+		if pattern.Pattern == "" {
+			add(key, "pattern is empty")
+			continue
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			add(key, "invalid regular expression: %v", err)
+		}
+		if pattern.Case != "" && !slices.Contains(ticketCases, pattern.Case) {
+			add(key+".case", "unknown case %q, expected one of %s", pattern.Case, strings.Join(ticketCases, ", "))
+		}
+	}
+
+	if len(c.BranchCommands) == 0 {
+		add("branch_commands", "no branch commands are configured")
+	}
+	seen := make(map[string]bool)
+	checkName := func(key, name string) {
+		switch {
+		case strings.TrimSpace(name) == "":
+			add(key, "command name is empty")
+		case strings.ContainsAny(name, " \t/"):
+			add(key, "command %q must not contain whitespace or slashes", name)
+		case slices.Contains(builtinCommands, name):
+			add(key, "command %q collides with the built-in %q command", name, name)
+		case seen[name]:
+			add(key, "duplicate command %q", name)
+		}
+		seen[name] = true
+	}
+	for i, command := range c.BranchCommands {
+		key := fmt.Sprintf("branch_commands[%d]", i)
+		checkName(key, command.Name)
+		for j, alias := range command.Aliases {
+			checkName(fmt.Sprintf("%s.aliases[%d]", key, j), alias)
+		}
+		if strings.ContainsAny(command.Prefix, " \t") {
+			add(key+".prefix", "prefix %q must not contain whitespace", command.Prefix)
+		}
+	}
+
+	if c.BranchTemplate != "" {
+		if _, err := branch.Generate("type", "TICKET-1", []string{"description"}, branch.Options{Template: c.BranchTemplate}); err != nil {
+			add("branch_template", "%v", err)
+		} else if err := branch.Parseable(c.BranchTemplate); err != nil {
+			add("branch_template", "%v, so current, list and lint can't read the type, ticket or slug", err)
+		}
+	}
+
+	if c.MaxLength < 0 {
+		add("max_length", "must not be negative")
+	}
+
+	if c.TicketCase != "" && !slices.Contains(ticketCases, c.TicketCase) {
+		add("ticket_case", "unknown case %q, expected one of %s", c.TicketCase, strings.Join(ticketCases, ", "))
+	}
+
+	if c.Transliterate != "" && !slices.Contains(branch.Locales(), c.Transliterate) {
+		add("transliterate", "unknown locale %q, expected one of %s", c.Transliterate, strings.Join(branch.Locales(), ", "))
+	}
+
+	if c.GitBackend != "" && !slices.Contains(git.Backends(), c.GitBackend) {
+		add("git_backend", "unknown backend %q, expected one of %s", c.GitBackend, strings.Join(git.Backends(), ", "))
+	}
+
+	if c.CreateStrategy != "" && !slices.Contains(git.Strategies(), c.CreateStrategy) {
+		add("create_strategy", "unknown strategy %q, expected one of %s", c.CreateStrategy, strings.Join(git.Strategies(), ", "))
+	}
+
+	if c.DirtyPolicy != "" && !slices.Contains(git.DirtyPolicies(), c.DirtyPolicy) {
+		add("dirty_policy", "unknown policy %q, expected one of %s", c.DirtyPolicy, strings.Join(git.DirtyPolicies(), ", "))
+	}
+
+	if c.OnCollision != "" && !slices.Contains(collisionPolicies, c.OnCollision) {
+		add("on_collision", "unknown policy %q, expected one of %s", c.OnCollision, strings.Join(collisionPolicies, ", "))
+	}
+
+	if c.CollisionSuffix != "" && strings.Count(c.CollisionSuffix, "%d") != 1 {
+		add("collision_suffix", "suffix %q must contain %%d once for the number", c.CollisionSuffix)
+	}
+
+	if strings.ContainsAny(c.Remote, " \t/") {
+		add("remote", "remote %q must not contain whitespace or slashes", c.Remote)
+	}
+
+	for i, pattern := range c.LintIgnore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			add(fmt.Sprintf("lint_ignore[%d]", i), "invalid pattern %q: %v", pattern, err)
+		}
+	}
+
+	if c.WorktreeDir != "" {
+		if _, err := branch.WorktreePath(c.WorktreeDir, "/", branch.WorktreeFields{
+			Fields: branch.Fields{Type: "type", Ticket: "ticket-1", Slug: "description"},
+			Repo:   "repo",
+			Branch: "type/ticket-1-description",
+		}); err != nil {
+			add("worktree_dir", "%v", err)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owenrumney/branch/internal/testutil"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("default config is valid", func(t *testing.T) {
+		if problems := Default().Validate(); len(problems) != 0 {
+			t.Errorf("Default() should be valid, got %v", problems)
+		}
+	})
+
+	t.Run("reports each problem with its key", func(t *testing.T) {
+		cfg := &Config{
+			TicketPatterns: []TicketPattern{
+				{Pattern: `^#\d+$`},
+				{Pattern: `invalid[regex`},
+				{Pattern: ""},
+				{Pattern: `^[A-Z]+-\d+$`, Case: "title"},
+			},
+			BranchCommands: []BranchCommand{
+				{Name: "feat"},
+				{Name: ""},
+				{Name: "feat"},
+				{Name: "help"},
+				{Name: "my cmd"},
+				{Name: "feature", Aliases: []string{"fix", "fix", "list"}, Prefix: "my feat"},
+			},
+			BranchTemplate:  "{{.Type",
+			MaxLength:       -1,
+			TicketCase:      "shout",
+			Transliterate:   "xx",
+			GitBackend:      "svn",
+			CreateStrategy:  "reset",
+			WorktreeDir:     "../{{.Repo",
+			DirtyPolicy:     "discard",
+			Remote:          "my remote",
+			OnCollision:     "overwrite",
+			CollisionSuffix: "-v",
+			LintIgnore:      []string{"main", "release/["},
+		}
+
+		wantKeys := []string{
+			"ticket_patterns[1]",
+			"ticket_patterns[2]",
+			"ticket_patterns[3].case",
+			"branch_commands[1]",
+			"branch_commands[2]",
+			"branch_commands[3]",
+			"branch_commands[4]",
+			"branch_commands[5].aliases[1]",
+			"branch_commands[5].aliases[2]",
+			"branch_commands[5].prefix",
+			"branch_template",
+			"max_length",
+			"ticket_case",
+			"transliterate",
+			"git_backend",
+			"create_strategy",
+			"dirty_policy",
+			"on_collision",
+			"collision_suffix",
+			"remote",
+			"lint_ignore[1]",
+			"worktree_dir",
+		}
+
+		problems := cfg.Validate()
+		if len(problems) != len(wantKeys) {
+			t.Fatalf("Expected %d problems, got %d: %v", len(wantKeys), len(problems), problems)
+		}
+		for i, key := range wantKeys {
+			if problems[i].Key != key {
+				t.Errorf("problems[%d].Key = %q, want %q", i, problems[i].Key, key)
+			}
+		}
+	})
+
+	t.Run("template that can't be parsed back", func(t *testing.T) {
+		cfg := Default()
+		cfg.BranchTemplate = "{{.Type}}/{{if .Ticket}}{{.Ticket}}-{{end}}{{.Slug}}"
+		problems := cfg.Validate()
+		if len(problems) != 1 || problems[0].Key != "branch_template" {
+			t.Errorf("Expected a branch_template problem, got %v", problems)
+		}
+	})
+
+	t.Run("no branch commands", func(t *testing.T) {
+		cfg := Default()
+		cfg.BranchCommands = nil
+		problems := cfg.Validate()
+		if len(problems) != 1 || problems[0].Key != "branch_commands" {
+			t.Errorf("Expected a branch_commands problem, got %v", problems)
+		}
+	})
+}
+
+func TestLoadCollectsProblems(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	testDir := t.TempDir()
+	configDir := filepath.Join(testDir, "branch")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	configData := `{"ticket_patterns": ["^#\\d+$", "invalid[regex"]}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configData), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	oldEnv := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldEnv) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", testDir)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load(nil) should not error, got: %v", err)
+	}
+
+	problems := cfg.Problems()
+	if len(problems) != 1 || problems[0].Key != "ticket_patterns[1]" {
+		t.Errorf("Expected a ticket_patterns[1] problem, got %v", problems)
+	}
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	testutil.IsolateEnv(t)
+
+	t.Run("explicit path", func(t *testing.T) {
+		t.Setenv(envConfigPath, filepath.Join(t.TempDir(), "missing.json"))
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		problems := cfg.Problems()
+		if len(problems) != 1 || problems[0].Key != envConfigPath {
+			t.Errorf("Expected a %s problem, got %v", envConfigPath, problems)
+		}
+	})
+
+	t.Run("default path", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		cfg, err := Load(nil)
+		if err != nil {
+			t.Fatalf("Load(nil) should not error, got: %v", err)
+		}
+		if problems := cfg.Problems(); len(problems) != 0 {
+			t.Errorf("Expected no problems, got %v", problems)
+		}
+	})
+}
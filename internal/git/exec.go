@@ -0,0 +1,381 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Exec is a Repository backed by the git binary.
+type Exec struct {
+	// Dir is the directory git runs in. Empty means the working directory.
+	Dir string
+}
+
+// NewExec returns a Repository that runs git in dir.
+func NewExec(dir string) *Exec {
+	return &Exec{Dir: dir}
+}
+
+// commandError carries git's own error output as the message.
+type commandError struct {
+	msg string
+	err error
+}
+
+func (e *commandError) Error() string { return e.msg }
+func (e *commandError) Unwrap() error { return e.err }
+
+// git runs a git command and returns its trimmed standard output.
+func (g *Exec) git(args ...string) (string, error) {
+	out, err := g.run(args...)
+	return strings.TrimSpace(out), err
+}
+
+// run runs a git command and returns its standard output as is.
+func (g *Exec) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", &commandError{msg: msg, err: err}
+	}
+	return string(out), nil
+}
+
+// exitCode returns the exit code of a failed git command, or -1 if it did
+// not run.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func (g *Exec) lines(args ...string) ([]string, error) {
+	out, err := g.git(args...)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (g *Exec) Root() (string, error) {
+	return g.git("rev-parse", "--show-toplevel")
+}
+
+func (g *Exec) CurrentBranch() (string, error) {
+	name, err := g.git("symbolic-ref", "--short", "--quiet", "HEAD")
+	if err != nil {
+		if exitCode(err) == 1 {
+			return "", fmt.Errorf("HEAD is detached")
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+func (g *Exec) Status() ([]string, error) {
+	// not trimmed, the first line may start with a space
+	out, err := g.run("status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+func (g *Exec) BranchExists(name string) (bool, error) {
+	if _, err := g.git("show-ref", "--verify", "--quiet", "refs/heads/"+name); err != nil {
+		if exitCode(err) == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *Exec) CreateBranch(name string, opts CreateOptions) error {
+	// Check if we're in a git repository
+	if _, err := g.git("rev-parse", "--git-dir"); err != nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	// Check if branch already exists
+	exists, err := g.BranchExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errBranchExists(name)
+	}
+
+	if opts.Fetch {
+		if err := g.fetch(opts.Base); err != nil {
+			return err
+		}
+	}
+
+	var args []string
+	switch {
+	case opts.Worktree != "":
+		args = []string{"worktree", "add", "-b", name, opts.Worktree}
+	case opts.Strategy == "" || opts.Strategy == StrategyCheckout:
+		args = []string{"checkout", "-b", name}
+	case opts.Strategy == StrategySwitch:
+		args = []string{"switch", "-c", name}
+	default:
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	if opts.Base != "" {
+		if _, err := g.git("rev-parse", "--verify", "--quiet", opts.Base+"^{commit}"); err != nil {
+			return errBaseNotFound(opts.Base)
+		}
+		if opts.Track {
+			args = append(args, "--track", opts.Base)
+		} else {
+			// don't track the base, the new branch gets its own upstream when pushed
+			args = append(args, "--no-track", opts.Base)
+		}
+	}
+
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, opts.Worktree != "", g.Status)
+	if err != nil {
+		return err
+	}
+	if stash {
+		if err := g.stash("creating " + name); err != nil {
+			return err
+		}
+	}
+
+	// Create the new branch and switch to it, here or in the new worktree
+	if _, err := g.git(args...); err != nil {
+		if stash {
+			// put the changes back where they were
+			_, _ = g.git("stash", "pop", "--index")
+		}
+		return err
+	}
+	if stash && opts.DirtyPolicy == DirtyMove {
+		return g.restore(name)
+	}
+	return nil
+}
+
+// restore re-applies the changes stashed before switching to branch. git
+// keeps the stash if they conflict.
+func (g *Exec) restore(branch string) error {
+	if _, err := g.git("stash", "pop", "--index"); err != nil {
+		// git's own message is vague, so name the files where possible
+		if out, _ := g.git("diff", "--name-only", "--diff-filter=U"); out != "" {
+			err = fmt.Errorf("conflicts in %s", strings.Join(strings.Split(out, "\n"), ", "))
+		}
+		return &RestoreError{Branch: branch, Err: err}
+	}
+	return nil
+}
+
+// stash stashes uncommitted changes, including untracked files, with a
+// message saying which branch they came from and why.
+func (g *Exec) stash(reason string) error {
+	current, _ := g.CurrentBranch()
+	msg := fmt.Sprintf("branch: changes on %s before %s", current, reason)
+	if _, err := g.git("stash", "push", "--include-untracked", "--message", msg); err != nil {
+		return fmt.Errorf("stash failed: %w", err)
+	}
+	return nil
+}
+
+func (g *Exec) fetch(base string) error {
+	remotes, err := g.lines("remote")
+	if err != nil {
+		return err
+	}
+
+	args := []string{"fetch", "--quiet"}
+	if remote := remoteOf(base, remotes); remote != "" {
+		args = append(args, remote)
+	}
+
+	if _, err := g.git(args...); err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	return nil
+}
+
+func (g *Exec) Checkout(name string, opts CheckoutOptions) error {
+	var args []string
+	switch {
+	case opts.Worktree != "":
+		args = []string{"worktree", "add", opts.Worktree, name}
+	case opts.Strategy == "" || opts.Strategy == StrategyCheckout:
+		// the trailing -- stops git treating the name as a path
+		args = []string{"checkout", name, "--"}
+	case opts.Strategy == StrategySwitch:
+		args = []string{"switch", name}
+	default:
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, opts.Worktree != "", g.Status)
+	if err != nil {
+		return err
+	}
+	if stash {
+		if err := g.stash("switching to " + name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := g.git(args...); err != nil {
+		if stash {
+			_, _ = g.git("stash", "pop", "--index")
+		}
+		return err
+	}
+	if stash && opts.DirtyPolicy == DirtyMove {
+		return g.restore(name)
+	}
+	return nil
+}
+
+func (g *Exec) ListBranches(remote bool) ([]Branch, error) {
+	patterns := []string{"refs/heads"}
+	if remote {
+		patterns = append(patterns, "refs/remotes")
+	}
+
+	refs, err := g.lines(append([]string{"for-each-ref", "--format=%(refname)"}, patterns...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, ref := range refs {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			branches = append(branches, Branch{Name: name})
+			continue
+		}
+		remoteName, name, _ := strings.Cut(strings.TrimPrefix(ref, "refs/remotes/"), "/")
+		// skip the symbolic origin/HEAD ref
+		if name == "HEAD" {
+			continue
+		}
+		branches = append(branches, Branch{Name: name, Remote: remoteName})
+	}
+	return branches, nil
+}
+
+func (g *Exec) LastCommit(ref string) (time.Time, error) {
+	out, err := g.git("log", "-1", "--format=%cI", ref, "--")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, out)
+}
+
+func (g *Exec) AheadBehind(ref, base string) (int, int, error) {
+	out, err := g.git("rev-list", "--left-right", "--count", ref+"..."+base, "--")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var ahead, behind int
+	if _, err := fmt.Sscanf(out, "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return ahead, behind, nil
+}
+
+func (g *Exec) DeleteBranch(name string) error {
+	_, err := g.git("branch", "--delete", name)
+	return err
+}
+
+func (g *Exec) RemoteRefs(remote string) ([]string, error) {
+	lines, err := g.lines("ls-remote", "--heads", remote)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(fields[1], "refs/heads/"))
+	}
+	return names, nil
+}
+
+func (g *Exec) Push(name, remote string) error {
+	// git's error output includes any messages from server-side hooks
+	if _, err := g.git("push", "--quiet", "--set-upstream", remote, name); err != nil {
+		return errPushFailed(remote, err)
+	}
+	return nil
+}
+
+func (g *Exec) Config(key string) (string, error) {
+	value, err := g.git("config", "--get", key)
+	if err != nil {
+		// exit code 1 means the key is not set
+		if exitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+func (g *Exec) ConfigSection(section string) ([]ConfigEntry, error) {
+	out, err := g.run("config", "--null", "--get-regexp", "^"+regexp.QuoteMeta(section)+`\.`)
+	if err != nil {
+		// exit code 1 means no keys in the section are set
+		if exitCode(err) == 1 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []ConfigEntry
+	for _, entry := range strings.Split(out, "\x00") {
+		if entry == "" {
+			continue
+		}
+		// entries are "name\nvalue", or just "name" for a bare boolean
+		name, value, hasValue := strings.Cut(entry, "\n")
+		if !hasValue {
+			value = "true"
+		}
+		entries = append(entries, ConfigEntry{Name: name, Value: value})
+	}
+	return entries, nil
+}
+
+func (g *Exec) HooksDir() (string, error) {
+	// git resolves core.hooksPath and linked worktrees itself
+	return g.git("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+}
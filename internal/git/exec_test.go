@@ -0,0 +1,391 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// initRepo creates a repository with a single commit on main and returns its path.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available, skipping test")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--initial-branch=main")
+	runGit(t, dir, "config", "--local", "user.email", "test@example.com")
+	runGit(t, dir, "config", "--local", "user.name", "Test User")
+	runGit(t, dir, "config", "--local", "commit.gpgsign", "false")
+	runGit(t, dir, "commit", "--allow-empty", "--no-gpg-sign", "-m", "Initial commit")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\nOutput: %s", args, err, string(output))
+	}
+	return string(output)
+}
+
+func TestExecRepository(t *testing.T) {
+	dir := initRepo(t)
+	repo := NewExec(dir)
+
+	t.Run("current branch", func(t *testing.T) {
+		got, err := repo.CurrentBranch()
+		if err != nil {
+			t.Fatalf("CurrentBranch() unexpected error: %v", err)
+		}
+		if got != "main" {
+			t.Errorf("CurrentBranch() = %q, want main", got)
+		}
+	})
+
+	t.Run("branch exists", func(t *testing.T) {
+		runGit(t, dir, "branch", "feat/exists")
+
+		if exists, err := repo.BranchExists("feat/exists"); err != nil || !exists {
+			t.Errorf("BranchExists(feat/exists) = %v, %v, want true", exists, err)
+		}
+		if exists, err := repo.BranchExists("feat/missing"); err != nil || exists {
+			t.Errorf("BranchExists(feat/missing) = %v, %v, want false", exists, err)
+		}
+	})
+
+	t.Run("checkout and delete", func(t *testing.T) {
+		runGit(t, dir, "branch", "fix/checkout")
+
+		if err := repo.Checkout("fix/checkout", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout() unexpected error: %v", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "fix/checkout" {
+			t.Errorf("CurrentBranch() after Checkout() = %q", got)
+		}
+
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+		if err := repo.DeleteBranch("fix/checkout"); err != nil {
+			t.Fatalf("DeleteBranch() unexpected error: %v", err)
+		}
+		if exists, _ := repo.BranchExists("fix/checkout"); exists {
+			t.Error("Branch should not exist after DeleteBranch()")
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		if got, err := repo.Config("user.name"); err != nil || got != "Test User" {
+			t.Errorf("Config(user.name) = %q, %v, want Test User", got, err)
+		}
+		if got, err := repo.Config("branch-tool.unset"); err != nil || got != "" {
+			t.Errorf("Config(unset) = %q, %v, want empty", got, err)
+		}
+	})
+
+	t.Run("remote branches", func(t *testing.T) {
+		remoteDir := t.TempDir()
+		runGit(t, remoteDir, "init", "--bare")
+		runGit(t, dir, "remote", "add", "origin", remoteDir)
+		runGit(t, dir, "push", "origin", "main", "feat/exists:feat/remote")
+		runGit(t, dir, "fetch", "origin")
+
+		refs, err := repo.RemoteRefs("origin")
+		if err != nil {
+			t.Fatalf("RemoteRefs() unexpected error: %v", err)
+		}
+		slices.Sort(refs)
+		if !slices.Equal(refs, []string{"feat/remote", "main"}) {
+			t.Errorf("RemoteRefs() = %v", refs)
+		}
+
+		branches, err := repo.ListBranches(true)
+		if err != nil {
+			t.Fatalf("ListBranches() unexpected error: %v", err)
+		}
+		var names []string
+		for _, b := range branches {
+			names = append(names, b.FullName())
+		}
+		for _, want := range []string{"main", "feat/exists", "origin/feat/remote", "origin/main"} {
+			if !slices.Contains(names, want) {
+				t.Errorf("ListBranches(true) = %v, missing %q", names, want)
+			}
+		}
+
+		local, err := repo.ListBranches(false)
+		if err != nil {
+			t.Fatalf("ListBranches(false) unexpected error: %v", err)
+		}
+		for _, b := range local {
+			if b.Remote != "" {
+				t.Errorf("ListBranches(false) returned remote branch %q", b.FullName())
+			}
+		}
+		if err := repo.Push("feat/exists", "origin"); err != nil {
+			t.Fatalf("Push() unexpected error: %v", err)
+		}
+		if got, _ := repo.Config("branch.feat/exists.remote"); got != "origin" {
+			t.Errorf("upstream remote = %q, want origin", got)
+		}
+
+		if err := repo.CreateBranch("feat/tracking", CreateOptions{Base: "origin/feat/remote", Track: true}); err != nil {
+			t.Fatalf("CreateBranch() with Track unexpected error: %v", err)
+		}
+		if got, _ := repo.Config("branch.feat/tracking.merge"); got != "refs/heads/feat/remote" {
+			t.Errorf("upstream = %q, want refs/heads/feat/remote", got)
+		}
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+
+		// a server-side hook that rejects every branch
+		hook := filepath.Join(remoteDir, "hooks", "pre-receive")
+		if err := os.WriteFile(hook, []byte("#!/bin/sh\necho 'branch names must start with a ticket' >&2\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("Failed to write hook: %v", err)
+		}
+		runGit(t, dir, "branch", "rejected")
+		err = repo.Push("rejected", "origin")
+		if err == nil || !strings.Contains(err.Error(), "branch names must start with a ticket") {
+			t.Errorf("Push() error = %v, want the hook's message", err)
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		got, err := repo.Root()
+		if err != nil {
+			t.Fatalf("Root() unexpected error: %v", err)
+		}
+		want, _ := filepath.EvalSymlinks(dir)
+		if got != want {
+			t.Errorf("Root() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("config section", func(t *testing.T) {
+		t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+		t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+		runGit(t, dir, "config", "branch-tool.commands", "feat")
+		runGit(t, dir, "config", "--add", "branch-tool.commands", "fix")
+		runGit(t, dir, "config", "branch-tool.maxLength", "50")
+		defer runGit(t, dir, "config", "--remove-section", "branch-tool")
+
+		// a bare key can only be written by hand
+		f, err := os.OpenFile(filepath.Join(dir, ".git", "config"), os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString("[branch-tool]\n\tmultipleTickets\n")
+		_ = f.Close()
+
+		got, err := repo.ConfigSection("branch-tool")
+		if err != nil {
+			t.Fatalf("ConfigSection() unexpected error: %v", err)
+		}
+		want := []ConfigEntry{
+			{Name: "branch-tool.commands", Value: "feat"},
+			{Name: "branch-tool.commands", Value: "fix"},
+			{Name: "branch-tool.maxlength", Value: "50"},
+			{Name: "branch-tool.multipletickets", Value: "true"},
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("ConfigSection() = %v, want %v", got, want)
+		}
+
+		if got, err := repo.ConfigSection("unset-section"); err != nil || len(got) != 0 {
+			t.Errorf("ConfigSection(unset) = %v, %v, want none", got, err)
+		}
+	})
+
+	t.Run("hooks dir", func(t *testing.T) {
+		root, _ := filepath.EvalSymlinks(dir)
+		if got, err := repo.HooksDir(); err != nil || got != filepath.Join(root, ".git", "hooks") {
+			t.Errorf("HooksDir() = %q, %v, want the git dir's hooks", got, err)
+		}
+
+		runGit(t, dir, "config", "core.hooksPath", ".githooks")
+		defer runGit(t, dir, "config", "--unset", "core.hooksPath")
+		if got, err := repo.HooksDir(); err != nil || got != filepath.Join(root, ".githooks") {
+			t.Errorf("HooksDir() = %q, %v, want core.hooksPath relative to the root", got, err)
+		}
+	})
+
+	t.Run("create with switch", func(t *testing.T) {
+		// a file named like the branch would make checkout ambiguous
+		if err := os.WriteFile(filepath.Join(dir, "login"), []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		defer os.Remove(filepath.Join(dir, "login"))
+
+		if err := repo.CreateBranch("login", CreateOptions{Strategy: StrategySwitch, Base: "main"}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "login" {
+			t.Errorf("CurrentBranch() = %q, want login", got)
+		}
+		runGit(t, dir, "checkout", "main", "--")
+
+		if err := repo.CreateBranch("other", CreateOptions{Strategy: "reset"}); err == nil {
+			t.Error("CreateBranch() should fail for an unknown strategy")
+		}
+	})
+
+	t.Run("create in worktree", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wt")
+		if err := repo.CreateBranch("feat/worktree", CreateOptions{Base: "main", Worktree: path}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "main" {
+			t.Errorf("CurrentBranch() = %q, the current worktree should stay on main", got)
+		}
+		if got, _ := NewExec(path).CurrentBranch(); got != "feat/worktree" {
+			t.Errorf("worktree branch = %q, want feat/worktree", got)
+		}
+	})
+
+	t.Run("checkout in worktree", func(t *testing.T) {
+		runGit(t, dir, "branch", "feat/existing", "main")
+		path := filepath.Join(t.TempDir(), "wt")
+		if err := repo.Checkout("feat/existing", CheckoutOptions{Worktree: path}); err != nil {
+			t.Fatalf("Checkout() unexpected error: %v", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "main" {
+			t.Errorf("CurrentBranch() = %q, the current worktree should stay on main", got)
+		}
+		if got, _ := NewExec(path).CurrentBranch(); got != "feat/existing" {
+			t.Errorf("worktree branch = %q, want feat/existing", got)
+		}
+	})
+
+	t.Run("dirty policy", func(t *testing.T) {
+		file := filepath.Join(dir, "dirty.txt")
+		if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		status, err := repo.Status()
+		if err != nil || !slices.Equal(status, []string{"?? dirty.txt"}) {
+			t.Fatalf("Status() = %q, %v, want the untracked file", status, err)
+		}
+
+		err = repo.CreateBranch("feat/require-clean", CreateOptions{DirtyPolicy: DirtyRequireClean})
+		var dirty *DirtyError
+		if !errors.As(err, &dirty) {
+			t.Fatalf("CreateBranch() error = %v, want a DirtyError", err)
+		}
+		if !strings.Contains(err.Error(), "dirty.txt") {
+			t.Errorf("error %q should list dirty.txt", err)
+		}
+
+		if err := repo.CreateBranch("feat/stash", CreateOptions{DirtyPolicy: DirtyStash}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if status, _ := repo.Status(); len(status) != 0 {
+			t.Errorf("Status() after stashing = %q, want clean", status)
+		}
+		if out := runGit(t, dir, "stash", "list"); !strings.Contains(out, "before creating feat/stash") {
+			t.Errorf("stash list = %q, should describe the stash", out)
+		}
+
+		runGit(t, dir, "checkout", "main", "--")
+		runGit(t, dir, "stash", "pop")
+
+		err = repo.Checkout("feat/stash", CheckoutOptions{DirtyPolicy: DirtyRequireClean})
+		if !errors.As(err, &dirty) {
+			t.Fatalf("Checkout() error = %v, want a DirtyError", err)
+		}
+		if err := repo.Checkout("feat/stash", CheckoutOptions{Strategy: StrategySwitch, DirtyPolicy: DirtyStash}); err != nil {
+			t.Fatalf("Checkout() unexpected error: %v", err)
+		}
+		if out := runGit(t, dir, "stash", "list"); !strings.Contains(out, "before switching to feat/stash") {
+			t.Errorf("stash list = %q, should describe the stash", out)
+		}
+
+		runGit(t, dir, "checkout", "main", "--")
+		runGit(t, dir, "stash", "pop")
+		if err := repo.CreateBranch("feat/carry", CreateOptions{DirtyPolicy: DirtyCarry}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("carried change should still be there: %v", err)
+		}
+		runGit(t, dir, "checkout", "main", "--")
+		_ = os.Remove(file)
+	})
+
+	t.Run("move changes", func(t *testing.T) {
+		file := filepath.Join(dir, "moved.txt")
+		writeFile := func(content string) {
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		writeFile("one\n")
+		runGit(t, dir, "add", "moved.txt")
+		runGit(t, dir, "commit", "--no-gpg-sign", "-m", "add moved.txt")
+		runGit(t, dir, "checkout", "-b", "base/changed")
+		writeFile("two\n")
+		runGit(t, dir, "commit", "--no-gpg-sign", "-am", "change moved.txt")
+		runGit(t, dir, "checkout", "main", "--")
+
+		writeFile("mine\n")
+		if err := repo.CreateBranch("feat/move", CreateOptions{DirtyPolicy: DirtyMove}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if status, _ := repo.Status(); !slices.Equal(status, []string{" M moved.txt"}) {
+			t.Errorf("Status() = %q, the change should be moved to the new branch", status)
+		}
+		if out := runGit(t, dir, "stash", "list"); out != "" {
+			t.Errorf("stash list = %q, the stash should be popped", out)
+		}
+
+		// the base changes the same line, so the changes can't be re-applied
+		runGit(t, dir, "checkout", "main", "--")
+		err := repo.CreateBranch("feat/move-conflict", CreateOptions{Base: "base/changed", DirtyPolicy: DirtyMove})
+		var restore *RestoreError
+		if !errors.As(err, &restore) || !strings.Contains(err.Error(), "moved.txt") {
+			t.Fatalf("CreateBranch() error = %v, want a RestoreError naming moved.txt", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "feat/move-conflict" {
+			t.Errorf("CurrentBranch() = %q, the branch should still be switched to", got)
+		}
+		if out := runGit(t, dir, "stash", "list"); !strings.Contains(out, "before creating feat/move-conflict") {
+			t.Errorf("stash list = %q, the changes should be kept in the stash", out)
+		}
+
+		runGit(t, dir, "reset", "--hard")
+		runGit(t, dir, "checkout", "main", "--")
+		runGit(t, dir, "stash", "drop")
+	})
+
+	t.Run("last commit and divergence", func(t *testing.T) {
+		runGit(t, dir, "branch", "feat/ahead", "main")
+		runGit(t, dir, "checkout", "feat/ahead", "--")
+		runGit(t, dir, "commit", "--allow-empty", "--no-gpg-sign", "-m", "ahead")
+		runGit(t, dir, "checkout", "main", "--")
+
+		ahead, behind, err := repo.AheadBehind("feat/ahead", "main")
+		if err != nil || ahead != 1 || behind != 0 {
+			t.Errorf("AheadBehind() = %d, %d, %v, want 1, 0", ahead, behind, err)
+		}
+		if _, _, err := repo.AheadBehind("feat/ahead", "missing"); err == nil {
+			t.Error("AheadBehind() should fail for a missing base")
+		}
+
+		updated, err := repo.LastCommit("feat/ahead")
+		if err != nil || time.Since(updated) > time.Hour {
+			t.Errorf("LastCommit() = %v, %v, want a recent date", updated, err)
+		}
+	})
+}
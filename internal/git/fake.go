@@ -0,0 +1,281 @@
+package git
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Fake is an in-memory Repository for tests. Branches are tracked by name
+// only; there are no commits or working tree.
+type Fake struct {
+	// Current is the checked out branch.
+	Current string
+	// Local holds the local branch names.
+	Local []string
+	// Remotes maps each remote name to the branches it has.
+	Remotes map[string][]string
+	// Settings holds git config values by key.
+	Settings map[string]string
+	// Bases records the base each branch created through CreateBranch started from.
+	Bases map[string]string
+	// Worktrees maps each branch checked out in a worktree to the worktree's path.
+	Worktrees map[string]string
+	// Strategies records the strategy each branch created through CreateBranch used.
+	Strategies map[string]string
+	// Dirty lists the uncommitted changes returned by Status.
+	Dirty []string
+	// Stashes holds the changes stashed by CreateBranch and Checkout, most
+	// recent last.
+	Stashes [][]string
+	// RestoreErr, if set, is returned by CreateBranch and Checkout when
+	// DirtyMove is set, to simulate changes that conflict with the branch.
+	// The changes are left in Stashes.
+	RestoreErr error
+	// Upstreams maps each branch pushed through Push, or created tracking a
+	// remote branch, to its remote.
+	Upstreams map[string]string
+	// PushErr, if set, is returned by Push to simulate a rejected push.
+	PushErr error
+	// Commits holds the last commit date of each ref, by full name.
+	Commits map[string]time.Time
+	// Divergence holds the ahead and behind counts of each ref, by full name,
+	// against any base.
+	Divergence map[string][2]int
+	// Fetches counts how many times a fetch was requested.
+	Fetches int
+	// Dir is returned as the repository root.
+	Dir string
+}
+
+// NewFake returns a Fake with main checked out and the given extra local branches.
+func NewFake(branches ...string) *Fake {
+	return &Fake{
+		Current:    "main",
+		Local:      append([]string{"main"}, branches...),
+		Remotes:    make(map[string][]string),
+		Settings:   make(map[string]string),
+		Bases:      make(map[string]string),
+		Worktrees:  make(map[string]string),
+		Strategies: make(map[string]string),
+		Upstreams:  make(map[string]string),
+		Commits:    make(map[string]time.Time),
+		Divergence: make(map[string][2]int),
+		Dir:        "/src/repo",
+	}
+}
+
+func (f *Fake) Root() (string, error) {
+	return f.Dir, nil
+}
+
+func (f *Fake) CurrentBranch() (string, error) {
+	if f.Current == "" {
+		return "", fmt.Errorf("HEAD is detached")
+	}
+	return f.Current, nil
+}
+
+func (f *Fake) Status() ([]string, error) {
+	return slices.Clone(f.Dirty), nil
+}
+
+func (f *Fake) BranchExists(name string) (bool, error) {
+	return slices.Contains(f.Local, name), nil
+}
+
+func (f *Fake) CreateBranch(name string, opts CreateOptions) error {
+	if slices.Contains(f.Local, name) {
+		return errBranchExists(name)
+	}
+
+	if opts.Strategy != "" && opts.Strategy != StrategyCheckout && opts.Strategy != StrategySwitch {
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	if opts.Fetch {
+		f.Fetches++
+	}
+
+	if opts.Base != "" && !f.refExists(opts.Base) {
+		return errBaseNotFound(opts.Base)
+	}
+
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, opts.Worktree != "", f.Status)
+	if err != nil {
+		return err
+	}
+	if stash {
+		f.Stashes = append(f.Stashes, f.Dirty)
+		f.Dirty = nil
+	}
+
+	f.Local = append(f.Local, name)
+	f.Bases[name] = opts.Base
+	if opts.Track {
+		remote, _, _ := strings.Cut(opts.Base, "/")
+		f.Upstreams[name] = remote
+	}
+	f.Strategies[name] = opts.Strategy
+	if opts.Worktree != "" {
+		// the new worktree has the branch checked out, not this one
+		f.Worktrees[name] = opts.Worktree
+		return nil
+	}
+	f.Current = name
+	if stash && opts.DirtyPolicy == DirtyMove {
+		return f.restore(name)
+	}
+	return nil
+}
+
+// restore pops the changes stashed before switching to branch, unless
+// RestoreErr is set.
+func (f *Fake) restore(branch string) error {
+	if f.RestoreErr != nil {
+		return &RestoreError{Branch: branch, Err: f.RestoreErr}
+	}
+	f.Dirty = f.Stashes[len(f.Stashes)-1]
+	f.Stashes = f.Stashes[:len(f.Stashes)-1]
+	return nil
+}
+
+func (f *Fake) refExists(ref string) bool {
+	if slices.Contains(f.Local, ref) {
+		return true
+	}
+	for remote, branches := range f.Remotes {
+		for _, branch := range branches {
+			if remote+"/"+branch == ref {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *Fake) Checkout(name string, opts CheckoutOptions) error {
+	if !slices.Contains(f.Local, name) {
+		return fmt.Errorf("pathspec %q did not match any file(s) known to git", name)
+	}
+	if opts.Strategy != "" && opts.Strategy != StrategyCheckout && opts.Strategy != StrategySwitch {
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	if opts.Worktree != "" {
+		f.Worktrees[name] = opts.Worktree
+		return nil
+	}
+
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, false, f.Status)
+	if err != nil {
+		return err
+	}
+	if stash {
+		f.Stashes = append(f.Stashes, f.Dirty)
+		f.Dirty = nil
+	}
+	f.Current = name
+	if stash && opts.DirtyPolicy == DirtyMove {
+		return f.restore(name)
+	}
+	return nil
+}
+
+func (f *Fake) ListBranches(remote bool) ([]Branch, error) {
+	var branches []Branch
+	for _, name := range f.Local {
+		branches = append(branches, Branch{Name: name})
+	}
+	if remote {
+		for _, remoteName := range slices.Sorted(maps.Keys(f.Remotes)) {
+			for _, name := range f.Remotes[remoteName] {
+				branches = append(branches, Branch{Name: name, Remote: remoteName})
+			}
+		}
+	}
+	return branches, nil
+}
+
+func (f *Fake) LastCommit(ref string) (time.Time, error) {
+	if !f.refExists(ref) {
+		return time.Time{}, fmt.Errorf("unknown revision %q", ref)
+	}
+	return f.Commits[ref], nil
+}
+
+func (f *Fake) AheadBehind(ref, base string) (int, int, error) {
+	if !f.refExists(ref) {
+		return 0, 0, fmt.Errorf("unknown revision %q", ref)
+	}
+	if !f.refExists(base) {
+		return 0, 0, fmt.Errorf("unknown revision %q", base)
+	}
+	d := f.Divergence[ref]
+	return d[0], d[1], nil
+}
+
+func (f *Fake) DeleteBranch(name string) error {
+	i := slices.Index(f.Local, name)
+	if i < 0 {
+		return fmt.Errorf("branch %q not found", name)
+	}
+	if name == f.Current {
+		return fmt.Errorf("cannot delete branch %q checked out", name)
+	}
+	f.Local = slices.Delete(f.Local, i, i+1)
+	return nil
+}
+
+func (f *Fake) RemoteRefs(remote string) ([]string, error) {
+	branches, ok := f.Remotes[remote]
+	if !ok {
+		return nil, fmt.Errorf("%q does not appear to be a git repository", remote)
+	}
+	return slices.Clone(branches), nil
+}
+
+func (f *Fake) Push(name, remote string) error {
+	if !slices.Contains(f.Local, name) {
+		return fmt.Errorf("src refspec %s does not match any", name)
+	}
+	if _, ok := f.Remotes[remote]; !ok {
+		return errPushFailed(remote, fmt.Errorf("%q does not appear to be a git repository", remote))
+	}
+	if f.PushErr != nil {
+		return errPushFailed(remote, f.PushErr)
+	}
+
+	if !slices.Contains(f.Remotes[remote], name) {
+		f.Remotes[remote] = append(f.Remotes[remote], name)
+	}
+	f.Upstreams[name] = remote
+	return nil
+}
+
+func (f *Fake) Config(key string) (string, error) {
+	return f.Settings[key], nil
+}
+
+func (f *Fake) ConfigSection(section string) ([]ConfigEntry, error) {
+	var entries []ConfigEntry
+	for _, key := range slices.Sorted(maps.Keys(f.Settings)) {
+		if strings.HasPrefix(strings.ToLower(key), strings.ToLower(section)+".") {
+			entries = append(entries, ConfigEntry{Name: strings.ToLower(key), Value: f.Settings[key]})
+		}
+	}
+	return entries, nil
+}
+
+func (f *Fake) HooksDir() (string, error) {
+	if hooksPath := f.Settings["core.hooksPath"]; hooksPath != "" {
+		if filepath.IsAbs(hooksPath) {
+			return hooksPath, nil
+		}
+		return filepath.Join(f.Dir, hooksPath), nil
+	}
+	return filepath.Join(f.Dir, ".git", "hooks"), nil
+}
@@ -0,0 +1,128 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	repo := NewFake("fix/old")
+	repo.Remotes["origin"] = []string{"main", "feat/shared"}
+
+	if err := repo.CreateBranch("fix/old", CreateOptions{}); err == nil {
+		t.Error("CreateBranch() should fail when the branch exists")
+	}
+	if err := repo.CreateBranch("feat/x", CreateOptions{Base: "origin/missing"}); err == nil {
+		t.Error("CreateBranch() should fail when the base does not exist")
+	}
+
+	if err := repo.CreateBranch("feat/new", CreateOptions{Base: "origin/main", Fetch: true}); err != nil {
+		t.Fatalf("CreateBranch() unexpected error: %v", err)
+	}
+	if repo.Current != "feat/new" || repo.Bases["feat/new"] != "origin/main" || repo.Fetches != 1 {
+		t.Errorf("CreateBranch() left fake in unexpected state: %+v", repo)
+	}
+
+	if err := repo.DeleteBranch("feat/new"); err == nil {
+		t.Error("DeleteBranch() should refuse to delete the current branch")
+	}
+	if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+		t.Fatalf("Checkout() unexpected error: %v", err)
+	}
+	if err := repo.DeleteBranch("feat/new"); err != nil {
+		t.Fatalf("DeleteBranch() unexpected error: %v", err)
+	}
+
+	branches, _ := repo.ListBranches(true)
+	if len(branches) != 4 {
+		t.Errorf("ListBranches(true) = %v, want 4 branches", branches)
+	}
+}
+
+func TestFakeDirtyPolicy(t *testing.T) {
+	repo := NewFake()
+	repo.Dirty = []string{" M main.go"}
+
+	err := repo.CreateBranch("feat/clean", CreateOptions{DirtyPolicy: DirtyRequireClean})
+	var dirty *DirtyError
+	if !errors.As(err, &dirty) || len(dirty.Files) != 1 {
+		t.Fatalf("CreateBranch() error = %v, want a DirtyError listing main.go", err)
+	}
+	if exists, _ := repo.BranchExists("feat/clean"); exists {
+		t.Error("Branch should not be created when the tree is dirty")
+	}
+
+	if err := repo.CreateBranch("feat/stashed", CreateOptions{DirtyPolicy: DirtyStash}); err != nil {
+		t.Fatalf("CreateBranch() unexpected error: %v", err)
+	}
+	if len(repo.Dirty) != 0 || len(repo.Stashes) != 1 {
+		t.Errorf("changes should be stashed, got dirty %v and stashes %v", repo.Dirty, repo.Stashes)
+	}
+
+	repo.Dirty = []string{" M main.go"}
+	if err := repo.CreateBranch("feat/moved", CreateOptions{DirtyPolicy: DirtyMove}); err != nil {
+		t.Fatalf("CreateBranch() unexpected error: %v", err)
+	}
+	if len(repo.Dirty) != 1 || len(repo.Stashes) != 1 {
+		t.Errorf("changes should be moved, got dirty %v and stashes %v", repo.Dirty, repo.Stashes)
+	}
+
+	repo.RestoreErr = errors.New("conflict")
+	err = repo.Checkout("main", CheckoutOptions{DirtyPolicy: DirtyMove})
+	var restore *RestoreError
+	if !errors.As(err, &restore) || repo.Current != "main" || len(repo.Stashes) != 2 {
+		t.Errorf("Checkout() error = %v, want a RestoreError with main checked out and the changes kept in the stash", err)
+	}
+
+	if err := repo.CreateBranch("feat/other", CreateOptions{DirtyPolicy: "discard"}); err == nil {
+		t.Error("CreateBranch() should fail for an unknown policy")
+	}
+}
+
+func TestFakePush(t *testing.T) {
+	repo := NewFake("feat/x")
+	repo.Remotes["origin"] = nil
+
+	if err := repo.Push("feat/missing", "origin"); err == nil {
+		t.Error("Push() should fail for a missing branch")
+	}
+	if err := repo.Push("feat/x", "upstream"); err == nil {
+		t.Error("Push() should fail for a missing remote")
+	}
+
+	if err := repo.Push("feat/x", "origin"); err != nil {
+		t.Fatalf("Push() unexpected error: %v", err)
+	}
+	if repo.Upstreams["feat/x"] != "origin" || len(repo.Remotes["origin"]) != 1 {
+		t.Errorf("Push() left fake in unexpected state: %+v", repo)
+	}
+
+	repo.PushErr = errors.New("pre-receive hook declined")
+	if err := repo.Push("main", "origin"); err == nil || !strings.Contains(err.Error(), "hook declined") {
+		t.Errorf("Push() error = %v, want the rejection", err)
+	}
+}
+
+func TestFakeHooksDir(t *testing.T) {
+	repo := NewFake()
+	if got, _ := repo.HooksDir(); got != "/src/repo/.git/hooks" {
+		t.Errorf("HooksDir() = %q, want the git dir's hooks", got)
+	}
+
+	repo.Settings["core.hooksPath"] = ".githooks"
+	if got, _ := repo.HooksDir(); got != "/src/repo/.githooks" {
+		t.Errorf("HooksDir() = %q, want core.hooksPath relative to the root", got)
+	}
+}
+
+func TestFakeConfigSection(t *testing.T) {
+	repo := NewFake()
+	repo.Settings["branch-tool.maxLength"] = "50"
+	repo.Settings["user.name"] = "Jane Doe"
+
+	got, _ := repo.ConfigSection("branch-tool")
+	if len(got) != 1 || got[0] != (ConfigEntry{Name: "branch-tool.maxlength", Value: "50"}) {
+		t.Errorf("ConfigSection() = %v, want only branch-tool.maxlength", got)
+	}
+}
@@ -4,25 +4,302 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 )
 
-func CreateBranch(name string) error {
-	// Check if we're in a git repository
-	if err := exec.Command("git", "rev-parse", "--git-dir").Run(); err != nil {
-		return fmt.Errorf("not a git repository")
+// Backends for the git_backend setting.
+const (
+	// BackendAuto uses the git binary when it is on the PATH, and go-git otherwise.
+	BackendAuto = "auto"
+	// BackendExec shells out to the git binary.
+	BackendExec = "exec"
+	// BackendGoGit uses the pure Go go-git implementation.
+	BackendGoGit = "go-git"
+)
+
+// Backends returns the supported git_backend values.
+func Backends() []string {
+	return []string{BackendAuto, BackendExec, BackendGoGit}
+}
+
+// New returns the Repository for backend, running in dir. An empty backend
+// is the same as BackendAuto.
+func New(backend, dir string) (Repository, error) {
+	switch backend {
+	case "", BackendAuto:
+		if _, err := exec.LookPath("git"); err != nil {
+			return NewGoGit(dir), nil
+		}
+		return NewExec(dir), nil
+	case BackendExec:
+		if _, err := exec.LookPath("git"); err != nil {
+			return nil, fmt.Errorf("git is not available on the PATH")
+		}
+		return NewExec(dir), nil
+	case BackendGoGit:
+		return NewGoGit(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown git backend %q, expected one of %s", backend, strings.Join(Backends(), ", "))
+	}
+}
+
+// Strategies for switching to a new branch, set by create_strategy.
+const (
+	// StrategyCheckout creates the branch with git checkout -b.
+	StrategyCheckout = "checkout"
+	// StrategySwitch creates the branch with git switch -c, which never
+	// mistakes the branch name for a file path.
+	StrategySwitch = "switch"
+)
+
+// Strategies returns the supported create_strategy values.
+func Strategies() []string {
+	return []string{StrategyCheckout, StrategySwitch}
+}
+
+// Policies for uncommitted changes when creating a branch, set by dirty_policy.
+const (
+	// DirtyCarry brings uncommitted changes over to the new branch.
+	DirtyCarry = "carry"
+	// DirtyStash stashes uncommitted changes, so the new branch starts clean.
+	DirtyStash = "stash"
+	// DirtyRequireClean refuses to create a branch while there are
+	// uncommitted changes.
+	DirtyRequireClean = "require-clean"
+	// DirtyMove stashes uncommitted changes and re-applies them on the new
+	// branch. Unlike DirtyCarry, it works when the base changes the same files.
+	DirtyMove = "move"
+)
+
+// DirtyPolicies returns the supported dirty_policy values.
+func DirtyPolicies() []string {
+	return []string{DirtyCarry, DirtyStash, DirtyRequireClean, DirtyMove}
+}
+
+// DirtyError is returned by CreateBranch when DirtyRequireClean is set and
+// the working tree has uncommitted changes.
+type DirtyError struct {
+	// Files lists the changes in git status --short format, e.g. "M  main.go".
+	Files []string
+}
+
+func (e *DirtyError) Error() string {
+	return "working tree has uncommitted changes, commit or stash them first:\n  " + strings.Join(e.Files, "\n  ")
+}
+
+// RestoreError is returned by CreateBranch and Checkout when DirtyMove is
+// set and the stashed changes could not be re-applied on the branch. The
+// branch has still been switched to, and the changes are kept in the stash.
+type RestoreError struct {
+	Branch string
+	Err    error
+}
+
+func (e *RestoreError) Error() string {
+	return fmt.Sprintf("uncommitted changes could not be re-applied on %s, they are kept in the stash: %v", e.Branch, e.Err)
+}
+
+func (e *RestoreError) Unwrap() error {
+	return e.Err
+}
+
+// Repository is the set of git operations the branch commands rely on. The
+// Exec implementation shells out to the git binary, GoGit uses go-git where
+// the binary isn't installed, and Fake keeps everything in memory for tests.
+type Repository interface {
+	// Root returns the top-level directory of the working tree.
+	Root() (string, error)
+	// CurrentBranch returns the name of the checked out branch.
+	CurrentBranch() (string, error)
+	// Status lists uncommitted changes in git status --short format. It is
+	// empty when the working tree is clean.
+	Status() ([]string, error)
+	// BranchExists reports whether a local branch with the given name exists.
+	BranchExists(name string) (bool, error)
+	// CreateBranch creates a new branch and switches to it, or checks it out
+	// in a new worktree if opts.Worktree is set.
+	CreateBranch(name string, opts CreateOptions) error
+	// Checkout switches to an existing branch, or checks it out in a new
+	// worktree if opts.Worktree is set.
+	Checkout(name string, opts CheckoutOptions) error
+	// ListBranches returns the local branches, and the remote-tracking
+	// branches as well if remote is true.
+	ListBranches(remote bool) ([]Branch, error)
+	// LastCommit returns the commit date of the commit ref points at.
+	LastCommit(ref string) (time.Time, error)
+	// AheadBehind counts the commits ref has that base doesn't, and the
+	// commits base has that ref doesn't.
+	AheadBehind(ref, base string) (ahead, behind int, err error)
+	// DeleteBranch deletes a local branch.
+	DeleteBranch(name string) error
+	// RemoteRefs asks the remote for the names of its branches.
+	RemoteRefs(remote string) ([]string, error)
+	// Push pushes a local branch to the remote under the same name and sets
+	// it as the branch's upstream.
+	Push(name, remote string) error
+	// Config returns the value of a git config key, or an empty string if unset.
+	Config(key string) (string, error)
+	// ConfigSection returns every value set in a git config section, in the
+	// order git reads them, so later entries take precedence. A key set
+	// without a value, a bare boolean, has the value "true".
+	ConfigSection(section string) ([]ConfigEntry, error)
+	// HooksDir returns the absolute path of the directory git runs hooks
+	// from: core.hooksPath if it is set, or the hooks directory of the
+	// repository's git dir.
+	HooksDir() (string, error)
+}
+
+// ConfigEntry is a single git config value. Multi-valued keys have one entry
+// per value.
+type ConfigEntry struct {
+	// Name is the full key lower-cased, as git reports it, e.g.
+	// branch-tool.maxlength.
+	Name  string
+	Value string
+}
+
+// Branch is a local or remote-tracking branch.
+type Branch struct {
+	// Name is the branch name without any remote prefix.
+	Name string
+	// Remote is the remote a remote-tracking branch belongs to, empty for
+	// local branches.
+	Remote string
+}
+
+// FullName returns the name as git shows it, e.g. origin/feat/x for a
+// remote-tracking branch.
+func (b Branch) FullName() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return b.Remote + "/" + b.Name
+}
+
+// CreateOptions controls how a new branch is created.
+type CreateOptions struct {
+	// Base is the ref the branch starts from. Empty means the current HEAD.
+	Base string
+	// Track sets Base, which must be a remote-tracking branch, as the new
+	// branch's upstream. Otherwise the branch gets no upstream until pushed.
+	Track bool
+	// Fetch updates the remote before branching. The remote is taken from
+	// Base when it is a remote-tracking ref, otherwise the default remote is used.
+	Fetch bool
+	// Strategy is how the current worktree is switched to the new branch.
+	// Empty means StrategyCheckout.
+	Strategy string
+	// Worktree, if set, is the path of a new worktree to check the branch
+	// out in, leaving the current worktree where it is.
+	Worktree string
+	// DirtyPolicy is what happens to uncommitted changes in the current
+	// worktree. Empty means DirtyCarry. It is ignored when Worktree is set.
+	DirtyPolicy string
+}
+
+// CheckoutOptions controls how an existing branch is checked out.
+type CheckoutOptions struct {
+	// Strategy is how the worktree is switched to the branch. Empty means
+	// StrategyCheckout.
+	Strategy string
+	// Worktree, if set, is the path of a new worktree to check the branch
+	// out in, leaving the current worktree where it is.
+	Worktree string
+	// DirtyPolicy is what happens to uncommitted changes. Empty means
+	// DirtyCarry. It is ignored when Worktree is set.
+	DirtyPolicy string
+}
+
+func errBranchExists(name string) error {
+	return fmt.Errorf("branch %q already exists", name)
+}
+
+func errUnknownStrategy(strategy string) error {
+	return fmt.Errorf("unknown create strategy %q, expected one of %s", strategy, strings.Join(Strategies(), ", "))
+}
+
+func errUnknownDirtyPolicy(policy string) error {
+	return fmt.Errorf("unknown dirty policy %q, expected one of %s", policy, strings.Join(DirtyPolicies(), ", "))
+}
+
+// checkDirtyPolicy validates policy and reports whether uncommitted changes
+// need to be stashed, or returns a DirtyError if they are not allowed. They
+// are left alone when checking out into a new worktree.
+func checkDirtyPolicy(policy string, worktree bool, status func() ([]string, error)) (stash bool, err error) {
+	switch policy {
+	case "", DirtyCarry:
+		return false, nil
+	case DirtyStash, DirtyMove, DirtyRequireClean:
+	default:
+		return false, errUnknownDirtyPolicy(policy)
 	}
+	if worktree {
+		return false, nil
+	}
+
+	files, err := status()
+	if err != nil || len(files) == 0 {
+		return false, err
+	}
+	if policy == DirtyRequireClean {
+		return false, &DirtyError{Files: files}
+	}
+	return true, nil
+}
 
-	// Check if branch already exists
-	out, _ := exec.Command("git", "branch", "--list", name).Output()
-	if strings.TrimSpace(string(out)) != "" {
-		return fmt.Errorf("branch %q already exists", name)
+func errPushFailed(remote string, err error) error {
+	return fmt.Errorf("push to %s failed: %w", remote, err)
+}
+
+func errBaseNotFound(base string) error {
+	return fmt.Errorf("base ref %q not found", base)
+}
+
+// remoteOf returns the remote from remotes that ref starts with, e.g. origin
+// for origin/main, or an empty string if ref is not a remote-tracking ref.
+func remoteOf(ref string, remotes []string) string {
+	for _, remote := range remotes {
+		if strings.HasPrefix(ref, remote+"/") {
+			return remote
+		}
 	}
+	return ""
+}
 
-	// Create and switch to the new branch
-	cmd := exec.Command("git", "checkout", "-b", name)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+// CheckRefName reports why name can't be used as a branch name, following
+// the rules of git check-ref-format --branch, or nil if it can.
+func CheckRefName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("name is empty")
+	case name == "@":
+		return fmt.Errorf("name must not be @")
+	case strings.HasPrefix(name, "-"):
+		return fmt.Errorf("name must not start with -")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return fmt.Errorf("name must not start or end with /")
+	case strings.HasSuffix(name, "."):
+		return fmt.Errorf("name must not end with .")
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("name must not contain ..")
+	case strings.Contains(name, "//"):
+		return fmt.Errorf("name must not contain //")
+	case strings.Contains(name, "@{"):
+		return fmt.Errorf("name must not contain @{")
 	}
 
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("name must not contain %q", r)
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return fmt.Errorf("path component %q must not start with .", component)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return fmt.Errorf("path component %q must not end with .lock", component)
+		}
+	}
 	return nil
 }
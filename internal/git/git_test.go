@@ -1,6 +1,7 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -63,6 +64,8 @@ func TestCreateBranch(t *testing.T) {
 		t.Fatalf("Failed to create initial commit: %v\nOutput: %s", err, string(output))
 	}
 
+	repo := NewExec(testDir)
+
 	t.Run("create new branch successfully", func(t *testing.T) {
 		// Change to test directory so CreateBranch can find the git repo
 		if err := os.Chdir(testDir); err != nil {
@@ -70,7 +73,7 @@ func TestCreateBranch(t *testing.T) {
 		}
 
 		branchName := "feat/test-branch"
-		err := CreateBranch(branchName)
+		err := repo.CreateBranch(branchName, CreateOptions{})
 		if err != nil {
 			t.Fatalf("CreateBranch() should succeed, got error: %v", err)
 		}
@@ -101,7 +104,7 @@ func TestCreateBranch(t *testing.T) {
 		branchName := "fix/existing-branch"
 
 		// Create branch first time
-		if err := CreateBranch(branchName); err != nil {
+		if err := repo.CreateBranch(branchName, CreateOptions{}); err != nil {
 			t.Fatalf("First CreateBranch() should succeed: %v", err)
 		}
 
@@ -111,7 +114,7 @@ func TestCreateBranch(t *testing.T) {
 		_ = checkoutCmd.Run()
 
 		// Try to create again
-		err := CreateBranch(branchName)
+		err := repo.CreateBranch(branchName, CreateOptions{})
 		if err == nil {
 			t.Error("CreateBranch() should fail when branch already exists")
 		}
@@ -120,6 +123,60 @@ func TestCreateBranch(t *testing.T) {
 		}
 	})
 
+	t.Run("create branch from base ref", func(t *testing.T) {
+		if err := os.Chdir(testDir); err != nil {
+			t.Fatalf("Failed to change to test directory: %v", err)
+		}
+
+		headCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+		headCmd.Dir = testDir
+		out, err := headCmd.Output()
+		if err != nil {
+			t.Fatalf("Failed to read current branch: %v", err)
+		}
+		mainline := strings.TrimSpace(string(out))
+
+		// Move onto a side branch with an extra commit
+		for _, args := range [][]string{
+			{"checkout", "-b", "side"},
+			{"commit", "--no-gpg-sign", "--allow-empty", "-m", "side commit"},
+		} {
+			c := exec.Command("git", args...)
+			c.Dir = testDir
+			if output, err := c.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\nOutput: %s", args, err, string(output))
+			}
+		}
+
+		if err := repo.CreateBranch("feat/from-base", CreateOptions{Base: mainline}); err != nil {
+			t.Fatalf("CreateBranch() with base should succeed, got error: %v", err)
+		}
+
+		newHead, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+		baseHead, _ := exec.Command("git", "rev-parse", mainline).Output()
+		if string(newHead) != string(baseHead) {
+			t.Errorf("Branch should start at %s", mainline)
+		}
+
+		checkoutCmd := exec.Command("git", "checkout", mainline)
+		checkoutCmd.Dir = testDir
+		_ = checkoutCmd.Run()
+	})
+
+	t.Run("error when base ref does not exist", func(t *testing.T) {
+		if err := os.Chdir(testDir); err != nil {
+			t.Fatalf("Failed to change to test directory: %v", err)
+		}
+
+		err := repo.CreateBranch("feat/missing-base", CreateOptions{Base: "does-not-exist"})
+		if err == nil {
+			t.Fatal("CreateBranch() should fail when base ref does not exist")
+		}
+		if err.Error() != `base ref "does-not-exist" not found` {
+			t.Errorf("CreateBranch() error = %q, want %q", err.Error(), `base ref "does-not-exist" not found`)
+		}
+	})
+
 	t.Run("error when not in git repository", func(t *testing.T) {
 		// Change to a non-git directory
 		nonGitDir := t.TempDir()
@@ -127,7 +184,7 @@ func TestCreateBranch(t *testing.T) {
 			t.Fatalf("Failed to change directory: %v", err)
 		}
 
-		err := CreateBranch("feat/test")
+		err := NewExec(nonGitDir).CreateBranch("feat/test", CreateOptions{})
 		if err == nil {
 			t.Error("CreateBranch() should fail when not in git repository")
 		}
@@ -141,3 +198,45 @@ func TestCreateBranch(t *testing.T) {
 		}
 	})
 }
+
+func TestNew(t *testing.T) {
+	if repo, err := New(BackendGoGit, ""); err != nil {
+		t.Errorf("New(go-git) unexpected error: %v", err)
+	} else if _, ok := repo.(*GoGit); !ok {
+		t.Errorf("New(go-git) = %T, want *GoGit", repo)
+	}
+
+	if _, err := New("svn", ""); err == nil {
+		t.Error("New() should fail for an unknown backend")
+	}
+
+	// auto picks the binary when it is installed
+	want := "*git.Exec"
+	if _, err := exec.LookPath("git"); err != nil {
+		want = "*git.GoGit"
+	}
+	repo, err := New(BackendAuto, "")
+	if err != nil {
+		t.Fatalf("New(auto) unexpected error: %v", err)
+	}
+	if got := fmt.Sprintf("%T", repo); got != want {
+		t.Errorf("New(auto) = %s, want %s", got, want)
+	}
+}
+
+func TestCheckRefName(t *testing.T) {
+	valid := []string{"feat/pip-1234-add-login", "fix/v1.2", "main", "users/jane/wip"}
+	for _, name := range valid {
+		if err := CheckRefName(name); err != nil {
+			t.Errorf("CheckRefName(%q) unexpected error: %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "@", "-feat", "/feat", "feat/", "feat.", "feat..x", "feat//x", "feat@{1}",
+		"feat x", "feat~1", "feat^", "feat:x", "feat?", "feat*", "feat[x", "feat\\x", "feat/.hidden", "feat/x.lock"}
+	for _, name := range invalid {
+		if err := CheckRefName(name); err == nil {
+			t.Errorf("CheckRefName(%q) should fail", name)
+		}
+	}
+}
@@ -0,0 +1,609 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// GoGit is a Repository implemented in pure Go with go-git, for environments
+// where the git binary is not installed.
+type GoGit struct {
+	// Dir is the directory the repository is looked up from. Empty means the
+	// working directory.
+	Dir string
+}
+
+// NewGoGit returns a Repository that uses go-git on the repository containing dir.
+func NewGoGit(dir string) *GoGit {
+	return &GoGit{Dir: dir}
+}
+
+// open opens the repository on every call, like the git binary would, so
+// commands that don't need a repository still work outside of one.
+func (g *GoGit) open() (*gogit.Repository, error) {
+	dir := g.Dir
+	if dir == "" {
+		dir = "."
+	}
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if errors.Is(err, gogit.ErrRepositoryNotExists) {
+			return nil, fmt.Errorf("not a git repository")
+		}
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (g *GoGit) Root() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return worktree.Filesystem.Root(), nil
+}
+
+func (g *GoGit) CurrentBranch() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached")
+	}
+	return head.Target().Short(), nil
+}
+
+func (g *GoGit) Status() ([]string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	return status(repo)
+}
+
+func status(repo *gogit.Repository) ([]string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	st, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, path := range slices.Sorted(maps.Keys(st)) {
+		f := st[path]
+		if f.Staging == gogit.Unmodified && f.Worktree == gogit.Unmodified {
+			continue
+		}
+		files = append(files, fmt.Sprintf("%c%c %s", f.Staging, f.Worktree, path))
+	}
+	return files, nil
+}
+
+func (g *GoGit) BranchExists(name string) (bool, error) {
+	repo, err := g.open()
+	if err != nil {
+		return false, err
+	}
+	return branchExists(repo, name)
+}
+
+func branchExists(repo *gogit.Repository, name string) (bool, error) {
+	_, err := repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (g *GoGit) CreateBranch(name string, opts CreateOptions) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+
+	// go-git has no worktree support, and both strategies behave alike
+	if opts.Worktree != "" {
+		return fmt.Errorf("worktrees are not supported by the go-git backend")
+	}
+	if opts.Strategy != "" && opts.Strategy != StrategyCheckout && opts.Strategy != StrategySwitch {
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	exists, err := branchExists(repo, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errBranchExists(name)
+	}
+
+	// go-git can't stash, so only carrying changes or refusing is possible
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, false, func() ([]string, error) { return status(repo) })
+	if err != nil {
+		return err
+	}
+	if stash {
+		return fmt.Errorf("stashing changes is not supported by the go-git backend")
+	}
+
+	if opts.Fetch {
+		if err := g.fetch(repo, opts.Base); err != nil {
+			return err
+		}
+	}
+
+	base := opts.Base
+	if base == "" {
+		base = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		if opts.Base == "" {
+			return fmt.Errorf("cannot create a branch without a commit: %w", err)
+		}
+		return errBaseNotFound(opts.Base)
+	}
+
+	err = switchTo(repo, &gogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Hash:   *hash,
+		Create: true,
+	}, *hash)
+	if err != nil || !opts.Track {
+		return err
+	}
+	return g.track(repo, name, opts.Base)
+}
+
+// track sets the remote-tracking branch base as the upstream of name.
+func (g *GoGit) track(repo *gogit.Repository, name, base string) error {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(remotes))
+	for _, remote := range remotes {
+		names = append(names, remote.Config().Name)
+	}
+
+	remote := remoteOf(base, names)
+	if remote == "" {
+		return fmt.Errorf("cannot track %q, it is not a remote-tracking branch", base)
+	}
+	return setUpstream(repo, name, remote, strings.TrimPrefix(base, remote+"/"))
+}
+
+// setUpstream records remote's branch as the upstream of the local branch name.
+func setUpstream(repo *gogit.Repository, name, remote, remoteBranch string) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[name] = &gitconfig.Branch{Name: name, Remote: remote, Merge: plumbing.NewBranchReferenceName(remoteBranch)}
+	return repo.SetConfig(cfg)
+}
+
+// switchTo checks out target. go-git can only keep uncommitted changes when
+// the commit doesn't change, so moving to another commit needs a clean tree.
+func switchTo(repo *gogit.Repository, opts *gogit.CheckoutOptions, target plumbing.Hash) error {
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	if head.Hash() == target {
+		// nothing to update, keep the index and working tree as they are
+		opts.Keep = true
+	} else {
+		files, err := status(repo)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			return fmt.Errorf("the go-git backend cannot carry uncommitted changes to a different commit:\n  %s", strings.Join(files, "\n  "))
+		}
+		// the tree is clean, so there is nothing to lose
+		opts.Force = true
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(opts)
+}
+
+func (g *GoGit) fetch(repo *gogit.Repository, base string) error {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(remotes))
+	for _, remote := range remotes {
+		names = append(names, remote.Config().Name)
+	}
+
+	remote := remoteOf(base, names)
+	if remote == "" {
+		remote = gogit.DefaultRemoteName
+	}
+
+	err = repo.Fetch(&gogit.FetchOptions{RemoteName: remote})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	return nil
+}
+
+func (g *GoGit) Checkout(name string, opts CheckoutOptions) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	if opts.Worktree != "" {
+		return fmt.Errorf("worktrees are not supported by the go-git backend")
+	}
+	if opts.Strategy != "" && opts.Strategy != StrategyCheckout && opts.Strategy != StrategySwitch {
+		return errUnknownStrategy(opts.Strategy)
+	}
+
+	stash, err := checkDirtyPolicy(opts.DirtyPolicy, false, func() ([]string, error) { return status(repo) })
+	if err != nil {
+		return err
+	}
+	if stash {
+		return fmt.Errorf("stashing changes is not supported by the go-git backend")
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return fmt.Errorf("pathspec %q did not match any file(s) known to git", name)
+		}
+		return err
+	}
+	return switchTo(repo, &gogit.CheckoutOptions{Branch: ref.Name()}, ref.Hash())
+}
+
+func (g *GoGit) ListBranches(remote bool) ([]Branch, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var local, remoteTracking []Branch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		switch {
+		case ref.Name().IsBranch():
+			local = append(local, Branch{Name: ref.Name().Short()})
+		case remote && ref.Name().IsRemote():
+			remoteName, name, _ := strings.Cut(strings.TrimPrefix(ref.Name().String(), "refs/remotes/"), "/")
+			// skip the symbolic origin/HEAD ref
+			if name != "HEAD" {
+				remoteTracking = append(remoteTracking, Branch{Name: name, Remote: remoteName})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortBranches(local)
+	sortBranches(remoteTracking)
+	return append(local, remoteTracking...), nil
+}
+
+func (g *GoGit) LastCommit(ref string) (time.Time, error) {
+	repo, err := g.open()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	commit, err := resolveCommit(repo, ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return commit.Committer.When, nil
+}
+
+func (g *GoGit) AheadBehind(ref, base string) (int, int, error) {
+	repo, err := g.open()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	refHistory, err := history(repo, ref)
+	if err != nil {
+		return 0, 0, err
+	}
+	baseHistory, err := history(repo, base)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var ahead, behind int
+	for hash := range refHistory {
+		if !baseHistory[hash] {
+			ahead++
+		}
+	}
+	for hash := range baseHistory {
+		if !refHistory[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+func resolveCommit(repo *gogit.Repository, ref string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q: %w", ref, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// history returns every commit reachable from ref.
+func history(repo *gogit.Repository, ref string) (map[plumbing.Hash]bool, error) {
+	commit, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+func (g *GoGit) DeleteBranch(name string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+
+	exists, err := branchExists(repo, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("branch %q not found", name)
+	}
+	if current, err := g.CurrentBranch(); err == nil && current == name {
+		return fmt.Errorf("cannot delete branch %q checked out", name)
+	}
+
+	return repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(name))
+}
+
+func (g *GoGit) RemoteRefs(remote string) ([]string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := repo.Remote(remote)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.List(&gogit.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, ref := range refs {
+		if ref.Name().IsBranch() {
+			names = append(names, ref.Name().Short())
+		}
+	}
+	return names, nil
+}
+
+func (g *GoGit) Push(name, remote string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+
+	ref := plumbing.NewBranchReferenceName(name)
+	err = repo.Push(&gogit.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(ref + ":" + ref)},
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return errPushFailed(remote, err)
+	}
+
+	// set the upstream, as git push --set-upstream does
+	return setUpstream(repo, name, remote, name)
+}
+
+func (g *GoGit) Config(key string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	section, subsection, option, err := splitConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	configs, err := scopedConfigs(repo)
+	if err != nil {
+		return "", err
+	}
+
+	// the most specific scope that sets the key wins, as with git config --get
+	for _, cfg := range configs {
+		s := cfg.Raw.Section(section)
+		if subsection != "" {
+			if !s.HasSubsection(subsection) {
+				continue
+			}
+			if o := s.Subsection(subsection); o.HasOption(option) {
+				return o.Option(option), nil
+			}
+			continue
+		}
+		if s.HasOption(option) {
+			return s.Option(option), nil
+		}
+	}
+	return "", nil
+}
+
+func (g *GoGit) ConfigSection(section string) ([]ConfigEntry, error) {
+	repo, err := g.open()
+	if err != nil {
+		// outside a repository only the global and system config apply
+		repo = nil
+	}
+
+	configs, err := scopedConfigs(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ConfigEntry
+	// git reads the least specific scope first
+	for _, cfg := range slices.Backward(configs) {
+		if !cfg.Raw.HasSection(section) {
+			continue
+		}
+		for _, o := range cfg.Raw.Section(section).Options {
+			// go-git doesn't tell a bare key from an empty value, and a bare
+			// boolean is by far the more common of the two
+			value := o.Value
+			if value == "" {
+				value = "true"
+			}
+			entries = append(entries, ConfigEntry{Name: strings.ToLower(section + "." + o.Key), Value: value})
+		}
+	}
+	return entries, nil
+}
+
+// scopedConfigs returns the repository's local config, if repo isn't nil,
+// followed by the global and system config, most specific first.
+func scopedConfigs(repo *gogit.Repository) ([]*gitconfig.Config, error) {
+	var configs []*gitconfig.Config
+	if repo != nil {
+		local, err := repo.Config()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, local)
+	}
+	for _, scope := range []gitconfig.Scope{gitconfig.GlobalScope, gitconfig.SystemScope} {
+		// a missing or unreadable global or system file is the same as an empty one
+		if cfg, err := gitconfig.LoadConfig(scope); err == nil {
+			configs = append(configs, cfg)
+		}
+	}
+	return configs, nil
+}
+
+func (g *GoGit) HooksDir() (string, error) {
+	hooksPath, err := g.Config("core.hooksPath")
+	if err != nil {
+		return "", err
+	}
+
+	if hooksPath != "" {
+		// resolved like git does: ~ is the home directory, and relative
+		// paths are relative to the root of the working tree
+		if rest, ok := strings.CutPrefix(hooksPath, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(home, rest), nil
+		}
+		if filepath.IsAbs(hooksPath) {
+			return hooksPath, nil
+		}
+		root, err := g.Root()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(root, hooksPath), nil
+	}
+
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("repository has no git directory")
+	}
+
+	// a linked worktree's git dir points at the main one, which has the hooks
+	gitDir := storage.Filesystem().Root()
+	if commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		dir := strings.TrimSpace(string(commonDir))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gitDir, dir)
+		}
+		gitDir = dir
+	}
+	return filepath.Join(gitDir, "hooks"), nil
+}
+
+// splitConfigKey splits a key such as user.name or branch.feat/x.remote into
+// its section, subsection and option. The subsection may itself contain dots.
+func splitConfigKey(key string) (section, subsection, option string, err error) {
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first < 0 {
+		return "", "", "", fmt.Errorf("key does not contain a section: %s", key)
+	}
+	if first == last {
+		return key[:first], "", key[last+1:], nil
+	}
+	return key[:first], key[first+1 : last], key[last+1:], nil
+}
+
+// sortBranches orders branches by name, as git for-each-ref does.
+func sortBranches(branches []Branch) {
+	slices.SortFunc(branches, func(a, b Branch) int {
+		return strings.Compare(a.FullName(), b.FullName())
+	})
+}
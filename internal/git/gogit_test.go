@@ -0,0 +1,370 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// initGoGitRepo creates a repository with a single commit on main using
+// go-git, so the tests run without the git binary.
+func initGoGitRepo(t *testing.T) (string, *gogit.Repository) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := gogit.PlainInitWithOptions(dir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("Failed to read repo config: %v", err)
+	}
+	cfg.User.Name = "Test User"
+	cfg.User.Email = "test@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("Failed to write repo config: %v", err)
+	}
+
+	commitFile(t, dir, repo, "README.md")
+	return dir, repo
+}
+
+// commitFile writes and commits a file, returning the new commit.
+func commitFile(t *testing.T, dir string, repo *gogit.Repository, name string) plumbing.Hash {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatalf("Failed to add file: %v", err)
+	}
+	hash, err := worktree.Commit("Add "+name, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	return hash
+}
+
+func TestGoGitRepository(t *testing.T) {
+	dir, raw := initGoGitRepo(t)
+	repo := NewGoGit(dir)
+
+	t.Run("current branch", func(t *testing.T) {
+		got, err := repo.CurrentBranch()
+		if err != nil {
+			t.Fatalf("CurrentBranch() unexpected error: %v", err)
+		}
+		if got != "main" {
+			t.Errorf("CurrentBranch() = %q, want main", got)
+		}
+	})
+
+	t.Run("create branch", func(t *testing.T) {
+		if err := repo.CreateBranch("feat/test-branch", CreateOptions{}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if got, _ := repo.CurrentBranch(); got != "feat/test-branch" {
+			t.Errorf("CurrentBranch() after CreateBranch() = %q", got)
+		}
+		if exists, err := repo.BranchExists("feat/test-branch"); err != nil || !exists {
+			t.Errorf("BranchExists(feat/test-branch) = %v, %v, want true", exists, err)
+		}
+
+		err := repo.CreateBranch("feat/test-branch", CreateOptions{})
+		if err == nil || err.Error() != `branch "feat/test-branch" already exists` {
+			t.Errorf("CreateBranch() on existing branch error = %v", err)
+		}
+
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+	})
+
+	t.Run("create branch from base ref", func(t *testing.T) {
+		main, err := raw.Reference(plumbing.NewBranchReferenceName("main"), false)
+		if err != nil {
+			t.Fatalf("Failed to resolve main: %v", err)
+		}
+
+		if err := repo.CreateBranch("side", CreateOptions{}); err != nil {
+			t.Fatalf("CreateBranch(side) unexpected error: %v", err)
+		}
+		commitFile(t, dir, raw, "side.txt")
+
+		if err := repo.CreateBranch("feat/from-base", CreateOptions{Base: "main"}); err != nil {
+			t.Fatalf("CreateBranch() with base unexpected error: %v", err)
+		}
+		head, err := raw.Head()
+		if err != nil {
+			t.Fatalf("Failed to resolve HEAD: %v", err)
+		}
+		if head.Hash() != main.Hash() {
+			t.Errorf("Branch should start at main, got %s", head.Hash())
+		}
+
+		err = repo.CreateBranch("feat/missing-base", CreateOptions{Base: "does-not-exist"})
+		if err == nil || err.Error() != `base ref "does-not-exist" not found` {
+			t.Errorf("CreateBranch() with missing base error = %v", err)
+		}
+
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+	})
+
+	t.Run("checkout and delete", func(t *testing.T) {
+		if err := repo.CreateBranch("fix/checkout", CreateOptions{}); err != nil {
+			t.Fatalf("CreateBranch() unexpected error: %v", err)
+		}
+		if err := repo.DeleteBranch("fix/checkout"); err == nil {
+			t.Error("DeleteBranch() should fail for the checked out branch")
+		}
+
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+		if err := repo.DeleteBranch("fix/checkout"); err != nil {
+			t.Fatalf("DeleteBranch() unexpected error: %v", err)
+		}
+		if exists, _ := repo.BranchExists("fix/checkout"); exists {
+			t.Error("Branch should not exist after DeleteBranch()")
+		}
+
+		if err := repo.Checkout("fix/missing", CheckoutOptions{}); err == nil {
+			t.Error("Checkout() should fail for a missing branch")
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		if got, err := repo.Config("user.name"); err != nil || got != "Test User" {
+			t.Errorf("Config(user.name) = %q, %v, want Test User", got, err)
+		}
+		if got, err := repo.Config("branch-tool.unset"); err != nil || got != "" {
+			t.Errorf("Config(unset) = %q, %v, want empty", got, err)
+		}
+	})
+
+	t.Run("remote branches", func(t *testing.T) {
+		remoteDir := t.TempDir()
+		if _, err := gogit.PlainInit(remoteDir, true); err != nil {
+			t.Fatalf("Failed to initialize remote: %v", err)
+		}
+		remote, err := raw.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+		if err != nil {
+			t.Fatalf("Failed to add remote: %v", err)
+		}
+		err = remote.Push(&gogit.PushOptions{RefSpecs: []gitconfig.RefSpec{
+			"refs/heads/main:refs/heads/main",
+			"refs/heads/side:refs/heads/feat/remote",
+		}})
+		if err != nil {
+			t.Fatalf("Failed to push: %v", err)
+		}
+
+		refs, err := repo.RemoteRefs("origin")
+		if err != nil {
+			t.Fatalf("RemoteRefs() unexpected error: %v", err)
+		}
+		slices.Sort(refs)
+		if !slices.Equal(refs, []string{"feat/remote", "main"}) {
+			t.Errorf("RemoteRefs() = %v", refs)
+		}
+
+		if err := repo.CreateBranch("feat/fetched", CreateOptions{Base: "origin/feat/remote", Fetch: true}); err != nil {
+			t.Fatalf("CreateBranch() with fetch unexpected error: %v", err)
+		}
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+
+		branches, err := repo.ListBranches(true)
+		if err != nil {
+			t.Fatalf("ListBranches() unexpected error: %v", err)
+		}
+		var names []string
+		for _, b := range branches {
+			names = append(names, b.FullName())
+		}
+		for _, want := range []string{"main", "feat/fetched", "origin/feat/remote", "origin/main"} {
+			if !slices.Contains(names, want) {
+				t.Errorf("ListBranches(true) = %v, missing %q", names, want)
+			}
+		}
+
+		local, err := repo.ListBranches(false)
+		if err != nil {
+			t.Fatalf("ListBranches(false) unexpected error: %v", err)
+		}
+		for _, b := range local {
+			if b.Remote != "" {
+				t.Errorf("ListBranches(false) returned remote branch %q", b.FullName())
+			}
+		}
+		if err := repo.CreateBranch("feat/tracking", CreateOptions{Base: "origin/feat/remote", Track: true}); err != nil {
+			t.Fatalf("CreateBranch() with Track unexpected error: %v", err)
+		}
+		if got, _ := repo.Config("branch.feat/tracking.merge"); got != "refs/heads/feat/remote" {
+			t.Errorf("upstream = %q, want refs/heads/feat/remote", got)
+		}
+		if err := repo.Checkout("main", CheckoutOptions{}); err != nil {
+			t.Fatalf("Checkout(main) unexpected error: %v", err)
+		}
+
+		if err := repo.Push("feat/fetched", "origin"); err != nil {
+			t.Fatalf("Push() unexpected error: %v", err)
+		}
+		if got, _ := repo.Config("branch.feat/fetched.remote"); got != "origin" {
+			t.Errorf("upstream remote = %q, want origin", got)
+		}
+		if refs, _ := repo.RemoteRefs("origin"); !slices.Contains(refs, "feat/fetched") {
+			t.Errorf("RemoteRefs() = %v, should include the pushed branch", refs)
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		if got, err := repo.Root(); err != nil || got != dir {
+			t.Errorf("Root() = %q, %v, want %q", got, err, dir)
+		}
+	})
+
+	t.Run("config section", func(t *testing.T) {
+		// keep the global config out of it
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		cfg, err := raw.Config()
+		if err != nil {
+			t.Fatalf("Failed to read repo config: %v", err)
+		}
+		cfg.Raw.AddOption("branch-tool", "", "commands", "feat")
+		cfg.Raw.AddOption("branch-tool", "", "commands", "fix")
+		cfg.Raw.AddOption("branch-tool", "", "maxLength", "50")
+		if err := raw.SetConfig(cfg); err != nil {
+			t.Fatalf("Failed to write repo config: %v", err)
+		}
+		defer func() {
+			cfg.Raw.RemoveSection("branch-tool")
+			_ = raw.SetConfig(cfg)
+		}()
+
+		got, err := repo.ConfigSection("branch-tool")
+		if err != nil {
+			t.Fatalf("ConfigSection() unexpected error: %v", err)
+		}
+		want := []ConfigEntry{
+			{Name: "branch-tool.commands", Value: "feat"},
+			{Name: "branch-tool.commands", Value: "fix"},
+			{Name: "branch-tool.maxlength", Value: "50"},
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("ConfigSection() = %v, want %v", got, want)
+		}
+
+		if got, err := NewGoGit(t.TempDir()).ConfigSection("branch-tool"); err != nil || len(got) != 0 {
+			t.Errorf("ConfigSection() outside a repository = %v, %v, want none", got, err)
+		}
+	})
+
+	t.Run("hooks dir", func(t *testing.T) {
+		if got, err := repo.HooksDir(); err != nil || got != filepath.Join(dir, ".git", "hooks") {
+			t.Errorf("HooksDir() = %q, %v, want the git dir's hooks", got, err)
+		}
+
+		cfg, err := raw.Config()
+		if err != nil {
+			t.Fatalf("Failed to read repo config: %v", err)
+		}
+		cfg.Raw.Section("core").SetOption("hooksPath", ".githooks")
+		if err := raw.SetConfig(cfg); err != nil {
+			t.Fatalf("Failed to write repo config: %v", err)
+		}
+		defer func() {
+			cfg.Raw.Section("core").RemoveOption("hooksPath")
+			_ = raw.SetConfig(cfg)
+		}()
+
+		if got, err := repo.HooksDir(); err != nil || got != filepath.Join(dir, ".githooks") {
+			t.Errorf("HooksDir() = %q, %v, want core.hooksPath relative to the root", got, err)
+		}
+	})
+
+	t.Run("worktrees are not supported", func(t *testing.T) {
+		err := repo.CreateBranch("feat/worktree", CreateOptions{Worktree: t.TempDir()})
+		if err == nil {
+			t.Error("CreateBranch() with a worktree should fail")
+		}
+		if exists, _ := repo.BranchExists("feat/worktree"); exists {
+			t.Error("Branch should not be created when the worktree fails")
+		}
+		if err := repo.Checkout("main", CheckoutOptions{Worktree: t.TempDir()}); err == nil {
+			t.Error("Checkout() with a worktree should fail")
+		}
+	})
+
+	t.Run("dirty policy", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0644); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		defer os.WriteFile(filepath.Join(dir, "README.md"), []byte("README.md"), 0644)
+
+		status, err := repo.Status()
+		if err != nil || !slices.Equal(status, []string{" M README.md"}) {
+			t.Fatalf("Status() = %q, %v, want README.md modified", status, err)
+		}
+
+		err = repo.CreateBranch("feat/require-clean", CreateOptions{DirtyPolicy: DirtyRequireClean})
+		var dirty *DirtyError
+		if !errors.As(err, &dirty) {
+			t.Errorf("CreateBranch() error = %v, want a DirtyError", err)
+		}
+		if err := repo.CreateBranch("feat/stash", CreateOptions{DirtyPolicy: DirtyStash}); err == nil {
+			t.Error("CreateBranch() should fail to stash with go-git")
+		}
+	})
+
+	t.Run("last commit and divergence", func(t *testing.T) {
+		// side has one commit more than main, see create branch from base ref
+		ahead, behind, err := repo.AheadBehind("side", "main")
+		if err != nil || ahead != 1 || behind != 0 {
+			t.Errorf("AheadBehind() = %d, %d, %v, want 1, 0", ahead, behind, err)
+		}
+		ahead, behind, err = repo.AheadBehind("main", "side")
+		if err != nil || ahead != 0 || behind != 1 {
+			t.Errorf("AheadBehind() = %d, %d, %v, want 0, 1", ahead, behind, err)
+		}
+
+		updated, err := repo.LastCommit("side")
+		if err != nil || time.Since(updated) > time.Hour {
+			t.Errorf("LastCommit() = %v, %v, want a recent date", updated, err)
+		}
+		if _, err := repo.LastCommit("missing"); err == nil {
+			t.Error("LastCommit() should fail for a missing ref")
+		}
+	})
+
+	t.Run("error when not in git repository", func(t *testing.T) {
+		err := NewGoGit(t.TempDir()).CreateBranch("feat/test", CreateOptions{})
+		if err == nil || err.Error() != "not a git repository" {
+			t.Errorf("CreateBranch() error = %v, want not a git repository", err)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// IsolateEnv unsets every BRANCH_* variable for the rest of the test, so
+// overrides in the developer's own environment don't leak into it.
+func IsolateEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "BRANCH_") {
+			// Setenv restores the variable once the test is done
+			t.Setenv(name, "")
+			_ = os.Unsetenv(name)
+		}
+	}
+}
@@ -3,10 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/exec"
 
 	"github.com/owenrumney/branch/cmd"
 	"github.com/owenrumney/branch/internal/config"
+	"github.com/owenrumney/branch/internal/git"
 )
 
 var (
@@ -16,19 +16,28 @@ var (
 )
 
 func main() {
-	// ensure that git is available on the PATH
-	if _, err := exec.LookPath("git"); err != nil {
-		fmt.Println("git is not available on the PATH")
+	// git config is read through the backend, so the backend is chosen from
+	// the config files and environment first
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		os.Exit(1)
+	}
+
+	// fall back to go-git when git is not on the PATH, unless told otherwise
+	repo, err := git.New(cfg.GitBackend, "")
+	if err != nil {
+		fmt.Println("Error selecting git backend:", err)
 		os.Exit(1)
 	}
 
-	cfg, err := config.Load()
+	cfg, err = config.Load(repo)
 	if err != nil {
 		fmt.Println("Error loading config:", err)
 		os.Exit(1)
 	}
 
-	rootCmd := cmd.NewRootCmd(cfg, fmt.Sprintf("%s (%s) built on %s", version, commit, date))
+	rootCmd := cmd.NewRootCmd(cfg, repo, fmt.Sprintf("%s (%s) built on %s", version, commit, date))
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println("Error executing command:", err)
 		os.Exit(1)